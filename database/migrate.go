@@ -0,0 +1,124 @@
+package database
+
+import (
+	"fmt"
+	"log"
+
+	"api_patroliku_docker/models"
+)
+
+// migrationModels berisi model untuk tabel baru yang dibuat lewat AutoMigrate
+var migrationModels = []interface{}{
+	&models.Incident{},
+	&models.IncidentComment{},
+	&models.Notification{},
+	&models.SOSEvent{},
+	&models.SOSEventLog{},
+	&models.LocationPing{},
+	&models.LeaveApprovalChain{},
+	&models.LeaveApproval{},
+	&models.LeaveQuota{},
+	&models.LeaveBalance{},
+	&models.TaskAssignHistory{},
+	&models.TaskTemplate{},
+	&models.TaskChecklistItem{},
+	&models.TaskChecklistResult{},
+	&models.TaskAssignComment{},
+	&models.TaskAssignCommentRead{},
+	&models.TaskEvidencePhoto{},
+	&models.TaskEvidenceNote{},
+	&models.ShiftSwapRequest{},
+	&models.HolidayCalendar{},
+}
+
+// migrationStatements berisi perubahan skema pada tabel yang sudah ada.
+// Semua statement harus idempotent karena dijalankan setiap aplikasi start.
+var migrationStatements = []string{
+	// Posisi & radius checkpoint patroli
+	`ALTER TABLE master_patroli ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION`,
+	`ALTER TABLE master_patroli ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION`,
+	`ALTER TABLE master_patroli ADD COLUMN IF NOT EXISTS radius INTEGER`,
+
+	// Hasil verifikasi lokasi patroli report
+	`ALTER TABLE patroli_report ADD COLUMN IF NOT EXISTS distance_meters DOUBLE PRECISION`,
+	`ALTER TABLE patroli_report ADD COLUMN IF NOT EXISTS outside_radius BOOLEAN NOT NULL DEFAULT FALSE`,
+
+	// Step approval leave yang sedang berjalan
+	`ALTER TABLE leave_new ADD COLUMN IF NOT EXISTS approval_step INTEGER NOT NULL DEFAULT 1`,
+
+	// Jumlah hari & pemotongan saldo leave
+	`ALTER TABLE leave_new ADD COLUMN IF NOT EXISTS days INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE leave_new ADD COLUMN IF NOT EXISTS deducted_days INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE leave_new ADD COLUMN IF NOT EXISTS days_next_year INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE leave_new ADD COLUMN IF NOT EXISTS special_approval BOOLEAN NOT NULL DEFAULT FALSE`,
+
+	// Attendance yang dibuat otomatis dari leave yang disetujui
+	`ALTER TABLE user_attendence ADD COLUMN IF NOT EXISTS leave_id INTEGER`,
+	`CREATE INDEX IF NOT EXISTS idx_user_attendence_leave_id ON user_attendence (leave_id)`,
+
+	// Pengelolaan task per branch
+	`ALTER TABLE task ADD COLUMN IF NOT EXISTS branch_id INTEGER`,
+	`ALTER TABLE task ADD COLUMN IF NOT EXISTS description TEXT`,
+	`ALTER TABLE task ADD COLUMN IF NOT EXISTS created_by INTEGER`,
+	`ALTER TABLE task ADD COLUMN IF NOT EXISTS created_at TIMESTAMP`,
+	`ALTER TABLE task ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP`,
+	`ALTER TABLE task_assign ADD COLUMN IF NOT EXISTS assigned_by INTEGER`,
+	`ALTER TABLE task_assign ADD COLUMN IF NOT EXISTS created_at TIMESTAMP`,
+
+	// Status task_assign lama ke status state machine
+	`UPDATE task_assign SET status = 'assigned' WHERE status IS NULL OR status IN ('', 'pending', 'new')`,
+	`UPDATE task_assign SET status = 'submitted' WHERE status = 'completed'`,
+
+	// Penugasan dari template task rutin, unik per template + guard + waktu kemunculan
+	`ALTER TABLE task_assign ADD COLUMN IF NOT EXISTS template_id INTEGER`,
+	`ALTER TABLE task_assign ADD COLUMN IF NOT EXISTS occurrence_at TIMESTAMP`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS uq_task_assign_template_occurrence ON task_assign (template_id, user_tad_id, occurrence_at)`,
+
+	// Review koordinator atas task yang disubmit
+	`ALTER TABLE task_assign ADD COLUMN IF NOT EXISTS review_score INTEGER`,
+	`ALTER TABLE task_assign ADD COLUMN IF NOT EXISTS review_note TEXT`,
+	`ALTER TABLE task_assign ADD COLUMN IF NOT EXISTS reviewed_by INTEGER`,
+	`ALTER TABLE task_assign ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMP`,
+
+	// Eskalasi penugasan overdue ke koordinator
+	`ALTER TABLE task_assign ADD COLUMN IF NOT EXISTS overdue_escalated_at TIMESTAMP`,
+	`CREATE INDEX IF NOT EXISTS idx_task_assign_status_end_time ON task_assign (status, end_time)`,
+
+	// Manajemen shift & jadwal guard
+	`ALTER TABLE schedule_shift ADD COLUMN IF NOT EXISTS branch_id INTEGER`,
+	`ALTER TABLE schedule_shift ADD COLUMN IF NOT EXISTS break_minutes INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE schedule_shift ADD COLUMN IF NOT EXISTS overnight BOOLEAN NOT NULL DEFAULT FALSE`,
+	`ALTER TABLE schedule_shift ADD COLUMN IF NOT EXISTS required_guards INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE schedule_shift ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP`,
+	`CREATE INDEX IF NOT EXISTS idx_schedule_users_id ON schedule (users_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_schedule_date_check_in ON schedule (date_check_in)`,
+
+	// Kalender hari libur & premi shift hari libur
+	`ALTER TABLE branch ADD COLUMN IF NOT EXISTS region VARCHAR(100)`,
+	`ALTER TABLE user_attendence ADD COLUMN IF NOT EXISTS holiday_premium BOOLEAN NOT NULL DEFAULT FALSE`,
+	`ALTER TABLE user_attendence ADD COLUMN IF NOT EXISTS holiday_id INTEGER`,
+}
+
+// Migrate membuat tabel baru dan menambahkan kolom yang dibutuhkan fitur terbaru
+func Migrate() error {
+	if DB == nil {
+		return fmt.Errorf("database belum terkoneksi")
+	}
+
+	if len(migrationModels) > 0 {
+		if err := DB.AutoMigrate(migrationModels...); err != nil {
+			return fmt.Errorf("auto migrate gagal: %v", err)
+		}
+	}
+
+	for _, stmt := range migrationStatements {
+		if err := DB.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("migration gagal (%s): %v", stmt, err)
+		}
+	}
+
+	log.Printf("✅ Database migration selesai (%d model, %d statement)",
+		len(migrationModels), len(migrationStatements))
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package events
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Tipe event yang dikirim ke feed supervisor
+const (
+	TypeCheckIn        = "attendance.check_in"
+	TypeCheckOut       = "attendance.check_out"
+	TypePatroliReport  = "patroli.report_saved"
+	TypeTaskEvidence   = "task.evidence_uploaded"
+	TypeTaskComment    = "task.comment_added"
+	TypeLeaveSubmitted = "leave.submitted"
+	TypeSOSCreated     = "sos.created"
+	TypeSOSUpdated     = "sos.updated"
+)
+
+// subscriberBuffer jumlah event yang ditampung per subscriber sebelum event dibuang
+const subscriberBuffer = 64
+
+// Event satu kejadian yang sudah tersimpan di database
+type Event struct {
+	ID        uint64      `json:"id"`
+	Type      string      `json:"type"`
+	BranchID  int         `json:"branch_id"`
+	UserID    int         `json:"user_id"`
+	Data      interface{} `json:"data"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// Subscription penerima event untuk branch tertentu
+type Subscription struct {
+	C        <-chan Event
+	ch       chan Event
+	branches map[int]bool // nil = semua branch
+	types    map[string]bool
+}
+
+func (s *Subscription) accepts(e Event) bool {
+	if s.branches != nil && !s.branches[e.BranchID] {
+		return false
+	}
+	if s.types != nil && !s.types[e.Type] {
+		return false
+	}
+	return true
+}
+
+// Bus pub/sub in-process. Publisher tidak pernah diblok oleh subscriber yang lambat.
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers map[*Subscription]struct{}
+	lastID      uint64
+}
+
+// NewBus membuat bus baru
+func NewBus() *Bus {
+	return &Bus{subscribers: make(map[*Subscription]struct{})}
+}
+
+// Default bus yang dipakai handler
+var Default = NewBus()
+
+// Publish mengirim event ke Default bus
+func Publish(e Event) {
+	Default.Publish(e)
+}
+
+// Publish mengirim event ke semua subscriber yang cocok
+func (b *Bus) Publish(e Event) {
+	e.ID = atomic.AddUint64(&b.lastID, 1)
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for sub := range b.subscribers {
+		if !sub.accepts(e) {
+			continue
+		}
+
+		select {
+		case sub.ch <- e:
+		default:
+			// subscriber terlalu lambat, event dibuang supaya publisher tidak tertahan
+		}
+	}
+}
+
+// Subscribe mendaftarkan subscriber. branchIDs kosong berarti semua branch,
+// types kosong berarti semua tipe event.
+func (b *Bus) Subscribe(branchIDs []int, types []string) *Subscription {
+	ch := make(chan Event, subscriberBuffer)
+	sub := &Subscription{C: ch, ch: ch}
+
+	if len(branchIDs) > 0 {
+		sub.branches = make(map[int]bool, len(branchIDs))
+		for _, id := range branchIDs {
+			sub.branches[id] = true
+		}
+	}
+
+	if len(types) > 0 {
+		sub.types = make(map[string]bool, len(types))
+		for _, t := range types {
+			sub.types[t] = true
+		}
+	}
+
+	b.mu.Lock()
+	b.subscribers[sub] = struct{}{}
+	b.mu.Unlock()
+
+	return sub
+}
+
+// Unsubscribe menghapus subscriber dan menutup channel-nya
+func (b *Bus) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subscribers[sub]; ok {
+		delete(b.subscribers, sub)
+		close(sub.ch)
+	}
+}
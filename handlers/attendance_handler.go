@@ -5,10 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"api_patroliku_docker/database"
+	"api_patroliku_docker/events"
 	"api_patroliku_docker/models"
+	"api_patroliku_docker/schedule"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -24,89 +27,77 @@ func NewAttendanceHandler() *AttendanceHandler {
 	}
 }
 
-func getCurrentDayNumber() int {
-	weekday := time.Now().Weekday()
-
-	// Sunday = 0 → 7
-	if weekday == time.Sunday {
-		return 7
-	}
-
-	return int(weekday)
+// scheduleBranchScan lokasi branch user untuk response jadwal
+type scheduleBranchScan struct {
+	UserName   sql.NullString
+	BranchID   sql.NullInt64
+	BranchName sql.NullString
+	Latitude   sql.NullFloat64
+	Longitude  sql.NullFloat64
+	Radius     sql.NullInt64
 }
 
-type attendanceScan struct {
-	Shift        sql.NullString
-	CheckinTime  sql.NullString
-	CheckoutTime sql.NullString
-	BranchName   sql.NullString
-	Latitude     sql.NullFloat64
-	Longitude    sql.NullFloat64
-	Holiday      bool
-	IDShift      int `gorm:"column:id_shift"`
-	IDSchedule   int `gorm:"column:id_schedule"`
+func (h *AttendanceHandler) scheduleBranch(userID int) (scheduleBranchScan, error) {
+	var branch scheduleBranchScan
+	err := h.DB.Raw(`
+		SELECT
+			u.name AS user_name,
+			b.id AS branch_id,
+			b.name AS branch_name,
+			b.latitude,
+			b.longitude,
+			b.radius
+		FROM users u
+		LEFT JOIN user_tad_information uti ON uti.user_id = u.id
+		LEFT JOIN branch b ON b.id = uti.branch_id
+		WHERE u.id = ?
+		LIMIT 1
+	`, userID).Scan(&branch).Error
+	return branch, err
 }
 
 // new
+// GetAttendanceScheduleService jadwal hari ini dari resolusi jadwal: jadwal bertanggal
+// menggantikan pola mingguan, hari libur tidak punya jadwal. Di hari libur kalender
+// shift tetap dikembalikan dan ditandai holiday_premium.
 func (h *AttendanceHandler) GetAttendanceScheduleService(userID int) (*models.AttendanceTodayResponse, error) {
-	day := getCurrentDayNumber()
-
-	var rows []attendanceScan
-
-	err := h.DB.Table("schedule s").
-		Select(`
-			ss.name as shift,
-			ss.start_time as checkin_time,
-			ss.end_time as checkout_time,
-			b.name as branch_name,
-			b.latitude,
-			b.longitude,
-			s.holiday,
-			ss.id as id_shift,
-			s.id as id_schedule
-		`).
-		Joins("LEFT JOIN schedule_shift ss ON ss.id = s.schedule_shift_id").
-		Joins("LEFT JOIN users u ON u.id = s.users_id").
-		Joins("LEFT JOIN user_tad_information uti ON uti.user_id = u.id").
-		Joins("LEFT JOIN branch b ON uti.branch_id = b.id").
-		Where("s.users_id = ? AND s.day = ?", userID, day).
-		Scan(&rows).Error
-
+	day, err := schedule.OnDate(h.DB, uint(userID), time.Now())
 	if err != nil {
 		return nil, err
 	}
 
 	// default response
 	response := &models.AttendanceTodayResponse{
-		Holiday:  false,
+		Holiday:  day.Holiday || day.PublicHoliday != nil,
 		Schedule: nil,
 	}
+	if day.PublicHoliday != nil {
+		response.HolidayName = day.PublicHoliday.Name
+	}
 
-	if len(rows) == 0 {
+	if day.Holiday || len(day.Shifts) == 0 {
 		return response, nil
 	}
 
-	// jika ada SATU data saja holiday = true
-	for _, r := range rows {
-		if r.Holiday {
-			response.Holiday = true
-			return response, nil
-		}
+	branch, err := h.scheduleBranch(userID)
+	if err != nil {
+		return nil, err
 	}
 
-	// ambil data pertama sebagai jadwal
-	r := rows[0]
+	// ambil shift pertama sebagai jadwal
+	shift := day.Shifts[0]
 
 	response.Schedule = &models.ScheduleResponse{
-		IDShift:      r.IDShift,
-		IDSchedule:   r.IDSchedule,
-		Shift:        r.Shift.String,
-		CheckinTime:  r.CheckinTime.String,
-		CheckoutTime: r.CheckoutTime.String,
+		IDShift:        shift.ShiftID,
+		IDSchedule:     shift.ScheduleID,
+		Shift:          shift.ShiftName,
+		CheckinTime:    shift.StartTime,
+		CheckoutTime:   shift.EndTime,
+		HolidayPremium: shift.HolidayPremium,
 		Branch: models.BranchResponse{
-			Name:      r.BranchName.String,
-			Latitude:  r.Latitude.Float64,
-			Longitude: r.Longitude.Float64,
+			Name:      branch.BranchName.String,
+			Latitude:  branch.Latitude.Float64,
+			Longitude: branch.Longitude.Float64,
 		},
 	}
 
@@ -170,14 +161,34 @@ func (h *AttendanceHandler) StoreAttendanceService(
 			AttendanceStatus: 1,
 			DocumentsClock:   doc,
 		}
+		if err := h.markHolidayPremium(&attendance); err != nil {
+			return err
+		}
 
-		return h.DB.Create(&attendance).Error
+		if err := h.DB.Create(&attendance).Error; err != nil {
+			return err
+		}
+
+		publishUserEvent(h.DB, events.TypeCheckIn, int(userID), gin.H{
+			"attendance_id": attendance.ID,
+			"schedule_id":   req.ScheduleID,
+			"check_in":      now,
+			"latitude":      req.Latitude,
+			"longitude":     req.Longitude,
+		})
+
+		return nil
 	}
 
 	if err != nil {
 		return err
 	}
 
+	// hari Sakit / Izin dari leave tidak boleh berubah menjadi Hadir
+	if isLeaveAttendance(attendance) {
+		return errAttendanceOnLeave
+	}
+
 	// =========================
 	// CHECK-OUT
 	// =========================
@@ -190,13 +201,23 @@ func (h *AttendanceHandler) StoreAttendanceService(
 		}
 		doc["check_out"] = req.Document
 
-		return h.DB.Model(&attendance).Updates(map[string]interface{}{
-			"check_out":            now,
-			"latitude_check_out":   req.Latitude,
-			"longitude_check_out":  req.Longitude,
-			"attendence_status_id": 2,
-			"documents_clock_out":  doc,
-		}).Error
+		if err := h.DB.Model(&attendance).Updates(map[string]interface{}{
+			"check_out":           now,
+			"latitude_check_out":  req.Latitude,
+			"longitude_check_out": req.Longitude,
+			"documents_clock_out": doc,
+		}).Error; err != nil {
+			return err
+		}
+
+		publishUserEvent(h.DB, events.TypeCheckOut, int(userID), gin.H{
+			"attendance_id": attendance.ID,
+			"check_out":     now,
+			"latitude":      req.Latitude,
+			"longitude":     req.Longitude,
+		})
+
+		return nil
 	}
 
 	return errors.New("anda sudah check-in dan check-out hari ini")
@@ -222,7 +243,11 @@ func (h *AttendanceHandler) StoreAttendance(c *gin.Context) {
 	}
 
 	if err := h.StoreAttendanceService(uint(userID), req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
+		code := http.StatusBadRequest
+		if errors.Is(err, errAttendanceOnLeave) {
+			code = http.StatusConflict
+		}
+		c.JSON(code, gin.H{
 			"message": err.Error(),
 		})
 		return
@@ -254,50 +279,40 @@ func (h *AttendanceHandler) GetAttendanceByUserAndDate(c *gin.Context) {
 		return
 	}
 
-	var result models.AttendanceResponse
-	var scheduleID sql.NullInt64
+	scheduleDate, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "format date harus YYYY-MM-DD",
+		})
+		return
+	}
 
-	query := `
-		SELECT 
-			ss.start_time as check_in,
-			ss.end_time as check_out,
-			u.name,
-			s.date_check_in as date,
-			b.latitude,
-			b.longitude,
-			b.radius,
-			b.id as branch_id,
-			s.id as id_schedule
-		FROM schedule s 
-		LEFT JOIN schedule_shift ss ON s.schedule_shift_id = ss.id
-		LEFT JOIN users u ON s.users_id = u.id
-		LEFT JOIN user_tad_information uti ON u.id = uti.user_id
-		LEFT JOIN branch b ON b.id = uti.branch_id
-		WHERE s.users_id = ?
-		  AND s.date_check_in = ?
-	`
-
-	row := h.DB.Raw(query, userID, date).Row()
-
-	err := row.Scan(
-		&result.CheckIn,
-		&result.CheckOut,
-		&result.Name,
-		&result.Date,
-		&result.Latitude,
-		&result.Longitude,
-		&result.Radius,
-		&result.BranchID,
-		&scheduleID,
-	)
+	id, err := strconv.Atoi(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "user_id tidak valid",
+		})
+		return
+	}
 
+	day, err := schedule.OnDate(h.DB, uint(id), scheduleDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "gagal mengambil data",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if len(day.Shifts) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"message": "data tidak ditemukan",
+		})
+		return
+	}
+
+	branch, err := h.scheduleBranch(id)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{
-				"message": "data tidak ditemukan",
-			})
-			return
-		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"message": "gagal mengambil data",
 			"error":   err.Error(),
@@ -305,9 +320,17 @@ func (h *AttendanceHandler) GetAttendanceByUserAndDate(c *gin.Context) {
 		return
 	}
 
-	// Handle nullable schedule ID
-	if scheduleID.Valid {
-		result.ScheduleID = scheduleID.Int64
+	shift := day.Shifts[0]
+	result := models.AttendanceResponse{
+		CheckIn:    shift.StartTime,
+		CheckOut:   shift.EndTime,
+		Name:       branch.UserName.String,
+		Date:       day.Date,
+		Latitude:   branch.Latitude.Float64,
+		Longitude:  branch.Longitude.Float64,
+		Radius:     int(branch.Radius.Int64),
+		BranchID:   branch.BranchID.Int64,
+		ScheduleID: int64(shift.ScheduleID),
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -407,6 +430,14 @@ func (h *AttendanceHandler) SaveAttendance(c *gin.Context) {
 			LatitudeCheckOut:  req.LatitudeCheckOut,
 			DocumentsClock:    documentsClock,
 		}
+		if err := h.markHolidayPremium(&attendance); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"message": "Gagal memeriksa kalender hari libur",
+				"error":   err.Error(),
+			})
+			return
+		}
 
 		if err := h.DB.Create(&attendance).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
@@ -491,6 +522,19 @@ func (h *AttendanceHandler) SaveAttendance(c *gin.Context) {
 
 // Helper untuk parse waktu string
 
+// markHolidayPremium menandai absensi di hari libur kalender untuk perhitungan premi
+func (h *AttendanceHandler) markHolidayPremium(attendance *models.UserAttendance) error {
+	holiday, err := schedule.HolidayOn(h.DB, attendance.UserID, attendance.DateAttendance)
+	if err != nil {
+		return err
+	}
+	if holiday != nil {
+		attendance.HolidayPremium = true
+		attendance.HolidayID = &holiday.ID
+	}
+	return nil
+}
+
 // Helper untuk map ke response
 func (h *AttendanceHandler) mapAttendanceToResponse(attendance models.UserAttendance) models.AttendanceSaveResponse {
 	response := models.AttendanceSaveResponse{
@@ -502,6 +546,8 @@ func (h *AttendanceHandler) mapAttendanceToResponse(attendance models.UserAttend
 		LatitudeCheckIn:  attendance.LatitudeCheckIn,
 		CreatedAt:        attendance.CreatedAt,
 		UpdatedAt:        attendance.UpdatedAt,
+		HolidayPremium:   attendance.HolidayPremium,
+		HolidayID:        attendance.HolidayID,
 	}
 
 	// ScheduleID (opsional)
@@ -606,6 +652,15 @@ func (h *AttendanceHandler) CheckIn(c *gin.Context) {
 			LatitudeCheckIn:  req.LatitudeCheckIn,
 			DocumentsClock:   documentsClock,
 		}
+		if err := h.markHolidayPremium(&attendance); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"message": "Gagal memeriksa kalender hari libur",
+				"error":   err.Error(),
+			})
+			return
+		}
 
 		if err := tx.Create(&attendance).Error; err != nil {
 			tx.Rollback()
@@ -619,6 +674,13 @@ func (h *AttendanceHandler) CheckIn(c *gin.Context) {
 
 		tx.Commit()
 
+		publishUserEvent(h.DB, events.TypeCheckIn, int(attendance.UserID), gin.H{
+			"attendance_id": attendance.ID,
+			"check_in":      attendance.CheckIn,
+			"latitude":      attendance.LatitudeCheckIn,
+			"longitude":     attendance.LongitudeCheckIn,
+		})
+
 		c.JSON(http.StatusCreated, gin.H{
 			"status":  "success",
 			"message": "Check-in berhasil disimpan",
@@ -629,6 +691,7 @@ func (h *AttendanceHandler) CheckIn(c *gin.Context) {
 				"check_in":          attendance.CheckIn.Format("15:04:05"),
 				"check_out":         nil,
 				"attendance_status": "Hadir",
+				"holiday_premium":   attendance.HolidayPremium,
 				"created_at":        attendance.CreatedAt,
 			},
 		})
@@ -643,6 +706,19 @@ func (h *AttendanceHandler) CheckIn(c *gin.Context) {
 
 	} else {
 		// UPDATE: Data sudah ada, update check-in saja
+		// Hari Sakit / Izin dari leave tidak boleh berubah menjadi Hadir
+		if isLeaveAttendance(existingAttendance) {
+			tx.Rollback()
+			c.JSON(http.StatusConflict, gin.H{
+				"status":  "error",
+				"message": errAttendanceOnLeave.Error(),
+				"data": gin.H{
+					"leave_id": existingAttendance.LeaveID,
+				},
+			})
+			return
+		}
+
 		// Cek apakah sudah check-in hari ini
 		if existingAttendance.CheckIn != nil {
 			tx.Rollback()
@@ -674,6 +750,20 @@ func (h *AttendanceHandler) CheckIn(c *gin.Context) {
 			}
 		}
 
+		if err := h.markHolidayPremium(&existingAttendance); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"message": "Gagal memeriksa kalender hari libur",
+				"error":   err.Error(),
+			})
+			return
+		}
+		if existingAttendance.HolidayPremium {
+			updates["holiday_premium"] = true
+			updates["holiday_id"] = existingAttendance.HolidayID
+		}
+
 		if err := tx.Model(&existingAttendance).Updates(updates).Error; err != nil {
 			tx.Rollback()
 			c.JSON(http.StatusInternalServerError, gin.H{
@@ -686,6 +776,13 @@ func (h *AttendanceHandler) CheckIn(c *gin.Context) {
 
 		tx.Commit()
 
+		publishUserEvent(h.DB, events.TypeCheckIn, int(existingAttendance.UserID), gin.H{
+			"attendance_id": existingAttendance.ID,
+			"check_in":      checkInTime,
+			"latitude":      req.LatitudeCheckIn,
+			"longitude":     req.LongitudeCheckIn,
+		})
+
 		c.JSON(http.StatusOK, gin.H{
 			"status":  "success",
 			"message": "Check-in berhasil diupdate",
@@ -835,6 +932,13 @@ func (h *AttendanceHandler) CheckOut(c *gin.Context) {
 
 	tx.Commit()
 
+	publishUserEvent(h.DB, events.TypeCheckOut, int(existingAttendance.UserID), gin.H{
+		"attendance_id": existingAttendance.ID,
+		"check_out":     checkOutTime,
+		"latitude":      req.LatitudeCheckOut,
+		"longitude":     req.LongitudeCheckOut,
+	})
+
 	// Response
 	response := gin.H{
 		"id":                existingAttendance.ID,
@@ -905,6 +1009,10 @@ func (h *AttendanceHandler) GetTodayAttendance(c *gin.Context) {
 		"has_check_in":      attendance.CheckIn != nil,
 		"has_check_out":     attendance.CheckOut != nil,
 		"attendance_status": attendance.AttendanceStatus,
+
+		"attendance_status_label": models.AttendanceLabel(attendance.AttendanceStatus, attendance.CheckIn != nil),
+		"on_leave":                attendance.LeaveID != nil && attendance.CheckIn == nil,
+		"leave_id":                attendance.LeaveID,
 	}
 
 	if attendance.CheckIn != nil {
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"api_patroliku_docker/middleware"
+	"api_patroliku_docker/models"
+	"api_patroliku_docker/schedule"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Status harian di rekap attendance
+const (
+	attendanceDayHadir      = "hadir"
+	attendanceDaySakit      = "sakit"
+	attendanceDayIzin       = "izin"
+	attendanceDayTidakHadir = "tidak_hadir" // terjadwal, sudah lewat, tanpa attendance
+	attendanceDayTerjadwal  = "terjadwal"   // terjadwal, belum lewat
+)
+
+type attendanceHistoryRow struct {
+	ID               uint       `gorm:"column:id"`
+	DateAttendance   time.Time  `gorm:"column:date_attendence"`
+	AttendanceStatus int        `gorm:"column:attendence_status_id"`
+	CheckIn          *time.Time `gorm:"column:check_in"`
+	CheckOut         *time.Time `gorm:"column:check_out"`
+	LeaveID          *uint      `gorm:"column:leave_id"`
+}
+
+// GetAttendanceHistory - GET /api/v1/attendance/history?user_id=258&start_date=2025-12-01&end_date=2025-12-31
+// Rekap per hari terjadwal: hadir, sakit / izin (termasuk dari leave), dan tidak hadir dibedakan.
+// Guard hanya melihat rekap sendiri, koordinator rekap guard di branch-nya.
+func (h *AttendanceHandler) GetAttendanceHistory(c *gin.Context) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  "error",
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	userID, err := strconv.Atoi(c.Query("user_id"))
+	if err != nil || userID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "user_id tidak valid",
+		})
+		return
+	}
+
+	// ===== akses: guard sendiri atau koordinator di branch guard =====
+	if userID != user.ID {
+		branchID, err := lookupUserBranchID(h.DB, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"message": "Gagal mengambil branch user",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		if !user.IsSupervisor() || !user.CanAccessBranch(branchID) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"status":  "error",
+				"message": "Anda tidak memiliki akses ke attendance user ini",
+			})
+			return
+		}
+	}
+
+	start, err := time.Parse("2006-01-02", c.Query("start_date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "format start_date harus YYYY-MM-DD",
+		})
+		return
+	}
+
+	end, err := time.Parse("2006-01-02", c.Query("end_date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "format end_date harus YYYY-MM-DD",
+		})
+		return
+	}
+
+	if end.Before(start) || int(end.Sub(start).Hours()/24)+1 > schedule.MaxRangeDays {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Rentang tanggal tidak valid",
+		})
+		return
+	}
+
+	shifts, err := schedule.Shifts(h.DB, uint(userID), start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil jadwal",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	var rows []attendanceHistoryRow
+	if err := h.DB.Table("user_attendence").
+		Select("id, date_attendence, attendence_status_id, check_in, check_out, leave_id").
+		Where("users_id = ? AND deleted_at IS NULL", userID).
+		Where("date_attendence BETWEEN ? AND ?", start.Format("2006-01-02"), end.Format("2006-01-02")).
+		Order("date_attendence ASC").
+		Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil data attendance",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	shiftByDate := map[string]schedule.Shift{}
+	for _, shift := range shifts {
+		if _, ok := shiftByDate[shift.Date]; !ok {
+			shiftByDate[shift.Date] = shift
+		}
+	}
+
+	attendanceByDate := map[string]attendanceHistoryRow{}
+	for _, row := range rows {
+		attendanceByDate[row.DateAttendance.Format("2006-01-02")] = row
+	}
+
+	today := time.Now().Format("2006-01-02")
+
+	summary := gin.H{
+		attendanceDayHadir:      0,
+		attendanceDaySakit:      0,
+		attendanceDayIzin:       0,
+		attendanceDayTidakHadir: 0,
+		attendanceDayTerjadwal:  0,
+		"leave":                 0,
+	}
+	days := []gin.H{}
+
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		date := d.Format("2006-01-02")
+
+		shift, scheduled := shiftByDate[date]
+		row, attended := attendanceByDate[date]
+		if !scheduled && !attended {
+			continue
+		}
+
+		status := attendanceDayTerjadwal
+		onLeave := false
+
+		switch {
+		case attended && row.CheckIn != nil:
+			status = attendanceDayHadir
+		case attended && row.AttendanceStatus == models.AttendanceStatusSakit:
+			status = attendanceDaySakit
+			onLeave = row.LeaveID != nil
+		case attended && row.AttendanceStatus == models.AttendanceStatusIzin:
+			status = attendanceDayIzin
+			onLeave = row.LeaveID != nil
+		case date < today:
+			status = attendanceDayTidakHadir
+		}
+
+		summary[status] = summary[status].(int) + 1
+		if onLeave {
+			summary["leave"] = summary["leave"].(int) + 1
+		}
+
+		day := gin.H{
+			"date":      date,
+			"status":    status,
+			"on_leave":  onLeave,
+			"leave_id":  nil,
+			"scheduled": scheduled,
+			"shift":     nil,
+			"check_in":  nil,
+			"check_out": nil,
+		}
+		if scheduled {
+			day["shift"] = shift
+		}
+		if attended {
+			day["attendance_id"] = row.ID
+			day["leave_id"] = row.LeaveID
+			if row.CheckIn != nil {
+				day["check_in"] = row.CheckIn.Format("15:04:05")
+			}
+			if row.CheckOut != nil {
+				day["check_out"] = row.CheckOut.Format("15:04:05")
+			}
+		}
+
+		days = append(days, day)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Riwayat attendance berhasil diambil",
+		"data": gin.H{
+			"user_id":    userID,
+			"start_date": start.Format("2006-01-02"),
+			"end_date":   end.Format("2006-01-02"),
+			"summary":    summary,
+			"days":       days,
+		},
+	})
+}
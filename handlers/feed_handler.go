@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"api_patroliku_docker/database"
+	"api_patroliku_docker/events"
+	"api_patroliku_docker/middleware"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// feedHeartbeatInterval ping berkala supaya koneksi tidak diputus proxy
+const feedHeartbeatInterval = 25 * time.Second
+
+type FeedHandler struct {
+	DB *gorm.DB
+}
+
+func NewFeedHandler() *FeedHandler {
+	return &FeedHandler{
+		DB: database.GetDB(),
+	}
+}
+
+// Stream - GET /api/v1/feed/stream (Server-Sent Events)
+// Query opsional: types=attendance.check_in,sos.created dan branch_id (khusus admin)
+func (h *FeedHandler) Stream(c *gin.Context) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  "error",
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	// ===== branch yang boleh dilihat =====
+	var branchIDs []int
+	if user.IsAdmin() {
+		for _, raw := range strings.Split(c.Query("branch_id"), ",") {
+			if id, err := strconv.Atoi(strings.TrimSpace(raw)); err == nil && id > 0 {
+				branchIDs = append(branchIDs, id)
+			}
+		}
+	} else {
+		branchIDs = []int{user.BranchID}
+	}
+
+	var types []string
+	for _, t := range strings.Split(c.Query("types"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, t)
+		}
+	}
+
+	sub := events.Default.Subscribe(branchIDs, types)
+	defer events.Default.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	c.SSEvent("connected", gin.H{
+		"user_id":   user.ID,
+		"branch_id": branchIDs,
+		"types":     types,
+	})
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(feedHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case e, ok := <-sub.C:
+			if !ok {
+				return false
+			}
+			c.Render(-1, sse.Event{
+				Id:    strconv.FormatUint(e.ID, 10),
+				Event: e.Type,
+				Data:  e,
+			})
+			return true
+		case t := <-heartbeat.C:
+			c.SSEvent("ping", gin.H{"time": t})
+			return true
+		}
+	})
+}
+
+// publishUserEvent mengirim event ke feed dengan branch diambil dari user terkait.
+// Dipanggil setelah data berhasil tersimpan.
+func publishUserEvent(db *gorm.DB, eventType string, userID int, data gin.H) {
+	branchID, err := lookupUserBranchID(db, userID)
+	if err != nil {
+		log.Printf("⚠️ Gagal mengambil branch user %d untuk event %s: %v", userID, eventType, err)
+		return
+	}
+
+	events.Publish(events.Event{
+		Type:     eventType,
+		BranchID: branchID,
+		UserID:   userID,
+		Data:     data,
+	})
+}
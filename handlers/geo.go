@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// earthRadiusMeters dipakai untuk perhitungan jarak haversine
+const earthRadiusMeters = 6371000.0
+
+// parseCoordinate mengubah latitude & longitude string menjadi float dan memvalidasi rentangnya
+func parseCoordinate(latStr, lngStr string) (float64, float64, error) {
+	lat, err := strconv.ParseFloat(strings.TrimSpace(latStr), 64)
+	if err != nil {
+		return 0, 0, errors.New("latitude harus berupa angka")
+	}
+
+	lng, err := strconv.ParseFloat(strings.TrimSpace(lngStr), 64)
+	if err != nil {
+		return 0, 0, errors.New("longitude harus berupa angka")
+	}
+
+	if err := validateCoordinate(lat, lng); err != nil {
+		return 0, 0, err
+	}
+
+	return lat, lng, nil
+}
+
+// validateCoordinate memastikan koordinat berada di rentang yang valid
+func validateCoordinate(lat, lng float64) error {
+	if math.IsNaN(lat) || lat < -90 || lat > 90 {
+		return errors.New("latitude harus di antara -90 dan 90")
+	}
+
+	if math.IsNaN(lng) || lng < -180 || lng > 180 {
+		return errors.New("longitude harus di antara -180 dan 180")
+	}
+
+	return nil
+}
+
+// haversineDistance menghitung jarak dua titik dalam meter
+func haversineDistance(lat1, lng1, lat2, lng2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLng := toRad(lng2 - lng1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return earthRadiusMeters * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// geoPoint satu titik koordinat untuk perhitungan track
+type geoPoint struct {
+	Lat  float64
+	Lng  float64
+	Time time.Time
+}
+
+// simplifyTrack menyederhanakan track dengan algoritma Douglas-Peucker.
+// toleranceMeters adalah jarak maksimum titik yang dibuang dari garis hasil penyederhanaan.
+func simplifyTrack(points []geoPoint, toleranceMeters float64) []geoPoint {
+	if len(points) < 3 || toleranceMeters <= 0 {
+		return points
+	}
+
+	keep := make([]bool, len(points))
+	keep[0], keep[len(points)-1] = true, true
+
+	type segment struct{ start, end int }
+	stack := []segment{{0, len(points) - 1}}
+
+	for len(stack) > 0 {
+		seg := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		maxDist, index := 0.0, -1
+		for i := seg.start + 1; i < seg.end; i++ {
+			d := perpendicularDistance(points[i], points[seg.start], points[seg.end])
+			if d > maxDist {
+				maxDist, index = d, i
+			}
+		}
+
+		if index != -1 && maxDist > toleranceMeters {
+			keep[index] = true
+			stack = append(stack, segment{seg.start, index}, segment{index, seg.end})
+		}
+	}
+
+	result := make([]geoPoint, 0, len(points))
+	for i, p := range points {
+		if keep[i] {
+			result = append(result, p)
+		}
+	}
+
+	return result
+}
+
+// perpendicularDistance jarak (meter) titik p ke garis a-b, memakai proyeksi equirectangular
+// yang cukup akurat untuk jarak pendek seperti track patroli
+func perpendicularDistance(p, a, b geoPoint) float64 {
+	toXY := func(pt geoPoint) (float64, float64) {
+		x := (pt.Lng - a.Lng) * math.Pi / 180 * math.Cos(a.Lat*math.Pi/180) * earthRadiusMeters
+		y := (pt.Lat - a.Lat) * math.Pi / 180 * earthRadiusMeters
+		return x, y
+	}
+
+	px, py := toXY(p)
+	bx, by := toXY(b)
+
+	length := math.Hypot(bx, by)
+	if length == 0 {
+		return math.Hypot(px, py)
+	}
+
+	return math.Abs(bx*py-by*px) / length
+}
+
+// trackDistance total panjang track dalam meter
+func trackDistance(points []geoPoint) float64 {
+	total := 0.0
+	for i := 1; i < len(points); i++ {
+		total += haversineDistance(points[i-1].Lat, points[i-1].Lng, points[i].Lat, points[i].Lng)
+	}
+	return total
+}
@@ -0,0 +1,378 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"api_patroliku_docker/middleware"
+	"api_patroliku_docker/models"
+	"api_patroliku_docker/schedule"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxHolidayImportSize batas ukuran file .ics yang diimport
+const maxHolidayImportSize = 2 << 20
+
+// holidayUniqueColumns kolom uq_holiday_calendar
+var holidayUniqueColumns = []clause.Column{
+	{Name: "date"}, {Name: "name"}, {Name: "scope"}, {Name: "region"}, {Name: "company_id"},
+}
+
+// GetHolidays - GET /api/v1/holidays?year=2026&branch_id=1
+// Hari libur yang berlaku untuk branch: nasional, regional sesuai region branch dan company branch.
+// Non-admin selalu melihat hari libur branch sendiri; admin tanpa branch_id melihat seluruh kalender.
+func (h *ScheduleHandler) GetHolidays(c *gin.Context) {
+	user, _ := middleware.CurrentUser(c)
+
+	year := time.Now().Year()
+	if raw := c.Query("year"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 2000 || parsed > 2100 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "year tidak valid",
+			})
+			return
+		}
+		year = parsed
+	}
+
+	branchID := user.BranchID
+	if user.IsAdmin() {
+		branchID = 0
+		if raw := c.Query("branch_id"); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil || parsed <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"status":  "error",
+					"message": "branch_id tidak valid",
+				})
+				return
+			}
+			branchID = parsed
+		}
+	}
+
+	query := h.DB.Model(&models.HolidayCalendar{}).
+		Where("deleted_at IS NULL AND EXTRACT(YEAR FROM date) = ?", year)
+	if branchID > 0 {
+		query = query.Where(`
+			scope = ?
+			OR (scope = ? AND EXISTS (
+				SELECT 1 FROM branch b
+				WHERE b.id = ? AND LOWER(TRIM(b.region)) = LOWER(TRIM(holiday_calendar.region))
+			))
+			OR (scope = ? AND company_id = (SELECT b.company_id FROM branch b WHERE b.id = ?))
+		`, models.HolidayScopeNational,
+			models.HolidayScopeRegional, branchID,
+			models.HolidayScopeCompany, branchID)
+	}
+	if scope := c.Query("scope"); scope != "" {
+		query = query.Where("scope = ?", scope)
+	}
+
+	var holidays []models.HolidayCalendar
+	if err := query.Order("date ASC, id ASC").Find(&holidays).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil hari libur",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Hari libur berhasil diambil",
+		"data":    holidays,
+	})
+}
+
+// CreateHoliday - POST /api/v1/holidays (koordinator)
+// Libur nasional dan regional hanya oleh admin; koordinator menambah libur company branch sendiri.
+// Hari libur yang sama yang pernah dihapus dipulihkan.
+func (h *ScheduleHandler) CreateHoliday(c *gin.Context) {
+	user, _ := middleware.CurrentUser(c)
+
+	var req models.HolidayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Data request tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Format date harus YYYY-MM-DD",
+		})
+		return
+	}
+
+	holiday := models.HolidayCalendar{
+		Date:      date,
+		Name:      strings.TrimSpace(req.Name),
+		Scope:     req.Scope,
+		Source:    models.HolidaySourceManual,
+		CreatedBy: uint(user.ID),
+	}
+	if !h.applyHolidayScope(c, &holiday, req.Region, req.CompanyID) {
+		return
+	}
+
+	if err := h.DB.Clauses(clause.OnConflict{
+		Columns:   holidayUniqueColumns,
+		DoUpdates: clause.AssignmentColumns([]string{"source", "created_by", "deleted_at", "updated_at"}),
+	}).Create(&holiday).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal menyimpan hari libur",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Hari libur berhasil disimpan",
+		"data":    holiday,
+	})
+}
+
+// DeleteHoliday - DELETE /api/v1/holidays/:id (koordinator)
+// Absensi yang sudah ditandai premi tidak diubah.
+func (h *ScheduleHandler) DeleteHoliday(c *gin.Context) {
+	user, _ := middleware.CurrentUser(c)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "ID hari libur tidak valid",
+		})
+		return
+	}
+
+	var holiday models.HolidayCalendar
+	err = h.DB.Where("id = ? AND deleted_at IS NULL", id).First(&holiday).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Hari libur tidak ditemukan",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil hari libur",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if !user.IsAdmin() {
+		companyID, err := branchCompanyID(h.DB, user.BranchID)
+		if err != nil || holiday.Scope != models.HolidayScopeCompany || holiday.CompanyID != companyID {
+			c.JSON(http.StatusForbidden, gin.H{
+				"status":  "error",
+				"message": "Anda tidak memiliki akses ke hari libur ini",
+			})
+			return
+		}
+	}
+
+	if err := h.DB.Model(&models.HolidayCalendar{}).
+		Where("id = ?", holiday.ID).
+		Update("deleted_at", time.Now()).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal menghapus hari libur",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Hari libur berhasil dihapus",
+	})
+}
+
+// ImportHolidays - POST /api/v1/holidays/import (admin, multipart: file, scope, region, company_id)
+// Import kalender iCal, mis. kalender libur nasional Indonesia. Hari libur yang sudah ada
+// (termasuk yang pernah dihapus) dilewati sehingga import bisa diulang.
+func (h *ScheduleHandler) ImportHolidays(c *gin.Context) {
+	user, _ := middleware.CurrentUser(c)
+	if !user.IsAdmin() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"message": "Hanya admin yang dapat mengimport kalender hari libur",
+		})
+		return
+	}
+
+	var req models.HolidayImportRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Data request tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if req.Scope == "" {
+		req.Scope = models.HolidayScopeNational
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "file .ics wajib diupload",
+		})
+		return
+	}
+	if fileHeader.Size > maxHolidayImportSize {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Ukuran file maksimal 2 MB",
+		})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal membaca file",
+			"error":   err.Error(),
+		})
+		return
+	}
+	defer file.Close()
+
+	parsed, err := schedule.ParseICal(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "File iCal tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	template := models.HolidayCalendar{Scope: req.Scope}
+	if !h.applyHolidayScope(c, &template, req.Region, req.CompanyID) {
+		return
+	}
+
+	holidays := make([]models.HolidayCalendar, 0, len(parsed))
+	for _, event := range parsed {
+		holiday := template
+		holiday.Date = event.Date
+		holiday.Name = event.Name
+		holiday.UID = event.UID
+		holiday.Source = models.HolidaySourceICal
+		holiday.CreatedBy = uint(user.ID)
+		holidays = append(holidays, holiday)
+	}
+
+	result := h.DB.Clauses(clause.OnConflict{Columns: holidayUniqueColumns, DoNothing: true}).
+		CreateInBatches(&holidays, 100)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengimport hari libur",
+			"error":   result.Error.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Kalender hari libur berhasil diimport",
+		"data": gin.H{
+			"imported": result.RowsAffected,
+			"skipped":  int64(len(holidays)) - result.RowsAffected,
+		},
+	})
+}
+
+// applyHolidayScope mengisi region / company_id sesuai scope dan memeriksa hak akses,
+// mengirim 400 / 403 jika tidak valid
+func (h *ScheduleHandler) applyHolidayScope(c *gin.Context, holiday *models.HolidayCalendar, region string, companyID uint) bool {
+	user, _ := middleware.CurrentUser(c)
+
+	switch holiday.Scope {
+	case models.HolidayScopeNational:
+		holiday.Region, holiday.CompanyID = "", 0
+	case models.HolidayScopeRegional:
+		holiday.Region, holiday.CompanyID = strings.TrimSpace(region), 0
+		if holiday.Region == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "region wajib diisi untuk hari libur regional",
+			})
+			return false
+		}
+	case models.HolidayScopeCompany:
+		holiday.Region, holiday.CompanyID = "", companyID
+		if !user.IsAdmin() {
+			own, err := branchCompanyID(h.DB, user.BranchID)
+			if err != nil || own == 0 {
+				c.JSON(http.StatusForbidden, gin.H{
+					"status":  "error",
+					"message": "Branch Anda tidak terdaftar di company",
+				})
+				return false
+			}
+			holiday.CompanyID = own
+		}
+		if holiday.CompanyID == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "company_id wajib diisi untuk hari libur company",
+			})
+			return false
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "scope tidak valid (national, regional, company)",
+		})
+		return false
+	}
+
+	if holiday.Scope != models.HolidayScopeCompany && !user.IsAdmin() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"message": "Hanya admin yang dapat mengatur hari libur nasional dan regional",
+		})
+		return false
+	}
+	return true
+}
+
+// branchCompanyID company pemilik branch, 0 jika branch tidak terdaftar di company
+func branchCompanyID(db *gorm.DB, branchID int) (uint, error) {
+	var companyID *uint
+	if err := db.Raw("SELECT company_id FROM branch WHERE id = ?", branchID).
+		Scan(&companyID).Error; err != nil {
+		return 0, err
+	}
+	if companyID == nil {
+		return 0, nil
+	}
+	return *companyID, nil
+}
@@ -0,0 +1,596 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"api_patroliku_docker/database"
+	"api_patroliku_docker/middleware"
+	"api_patroliku_docker/models"
+	"api_patroliku_docker/storage"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxIncidentPhotos batas jumlah foto per incident
+const maxIncidentPhotos = 10
+
+var errIncidentStatusChanged = errors.New("status incident sudah diubah oleh user lain")
+
+type IncidentHandler struct {
+	DB *gorm.DB
+}
+
+func NewIncidentHandler() *IncidentHandler {
+	return &IncidentHandler{
+		DB: database.GetDB(),
+	}
+}
+
+// GetIncidentCategories - GET /api/v1/incidents/categories
+func (h *IncidentHandler) GetIncidentCategories(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Kategori incident berhasil diambil",
+		"data": gin.H{
+			"categories": models.IncidentCategories,
+			"severities": models.IncidentSeverities,
+		},
+	})
+}
+
+// CreateIncident - POST /api/v1/incidents (multipart)
+func (h *IncidentHandler) CreateIncident(c *gin.Context) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  "error",
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	// ===== ambil form data =====
+	category := strings.ToLower(strings.TrimSpace(c.PostForm("category")))
+	severity := strings.ToLower(strings.TrimSpace(c.PostForm("severity")))
+	title := strings.TrimSpace(c.PostForm("title"))
+	description := c.PostForm("description")
+
+	if _, ok := models.IncidentCategories[category]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "category tidak valid",
+		})
+		return
+	}
+
+	if !isValidSeverity(severity) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "severity harus salah satu dari: " + strings.Join(models.IncidentSeverities, ", "),
+		})
+		return
+	}
+
+	if title == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "title wajib diisi",
+		})
+		return
+	}
+
+	incident := models.Incident{
+		UserID:      uint(user.ID),
+		BranchID:    uint(user.BranchID),
+		Category:    category,
+		Severity:    severity,
+		Title:       title,
+		Description: description,
+		Status:      models.IncidentStatusOpen,
+	}
+
+	// ===== lokasi (opsional) =====
+	latStr, lngStr := c.PostForm("latitude"), c.PostForm("longitude")
+	if latStr != "" || lngStr != "" {
+		lat, lng, err := parseCoordinate(latStr, lngStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": err.Error(),
+			})
+			return
+		}
+		incident.Latitude = &lat
+		incident.Longitude = &lng
+	}
+
+	// ===== checkpoint terkait (opsional) =====
+	if idPatroliStr := c.PostForm("id_patroli"); idPatroliStr != "" {
+		idPatroli, err := strconv.Atoi(idPatroliStr)
+		if err != nil || idPatroli <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "id_patroli tidak valid",
+			})
+			return
+		}
+		id := uint(idPatroli)
+		incident.IDPatroli = &id
+	}
+
+	// ===== upload foto & video =====
+	photos, video, err := incidentMediaFiles(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	for _, photo := range photos {
+		photoURL, err := storage.SaveUploadedFile(c, photo, "incident/photo")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"message": "gagal menyimpan foto incident",
+			})
+			return
+		}
+		incident.Photos = append(incident.Photos, photoURL)
+	}
+
+	if video != nil {
+		videoURL, err := storage.SaveUploadedFile(c, video, "incident/video")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"message": "gagal menyimpan video incident",
+			})
+			return
+		}
+		incident.VideoURL = &videoURL
+	}
+
+	if err := h.DB.Create(&incident).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal menyimpan incident",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Incident berhasil dilaporkan",
+		"data":    incident,
+	})
+}
+
+// incidentMediaFiles mengambil foto (field "photos", bisa lebih dari satu) dan video (field "video")
+func incidentMediaFiles(c *gin.Context) ([]*multipart.FileHeader, *multipart.FileHeader, error) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, nil, errors.New("request harus multipart/form-data")
+	}
+
+	photos := form.File["photos"]
+	if len(photos) > maxIncidentPhotos {
+		return nil, nil, errors.New("maksimal " + strconv.Itoa(maxIncidentPhotos) + " foto per incident")
+	}
+
+	for _, photo := range photos {
+		if !storage.IsImage(photo) {
+			return nil, nil, errors.New("format foto tidak didukung: " + photo.Filename)
+		}
+	}
+
+	var video *multipart.FileHeader
+	if videos := form.File["video"]; len(videos) > 0 {
+		video = videos[0]
+		if !storage.IsVideo(video) {
+			return nil, nil, errors.New("format video tidak didukung: " + video.Filename)
+		}
+	}
+
+	return photos, video, nil
+}
+
+func isValidSeverity(severity string) bool {
+	for _, s := range models.IncidentSeverities {
+		if s == severity {
+			return true
+		}
+	}
+	return false
+}
+
+// ListIncidents - GET /api/v1/incidents
+func (h *IncidentHandler) ListIncidents(c *gin.Context) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  "error",
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	// ===== pagination =====
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+
+	offset := (page - 1) * limit
+
+	// ===== filter =====
+	query := h.DB.Table("incident i").Where("i.deleted_at IS NULL")
+
+	if user.IsAdmin() {
+		if branchID := c.Query("branch_id"); branchID != "" {
+			query = query.Where("i.branch_id = ?", branchID)
+		}
+	} else {
+		query = query.Where("i.branch_id = ?", user.BranchID)
+	}
+
+	if status := c.Query("status"); status != "" {
+		query = query.Where("i.status = ?", status)
+	}
+	if category := c.Query("category"); category != "" {
+		query = query.Where("i.category = ?", category)
+	}
+	if severity := c.Query("severity"); severity != "" {
+		query = query.Where("i.severity = ?", severity)
+	}
+
+	if startDate := c.Query("start_date"); startDate != "" {
+		t, err := time.Parse("2006-01-02", startDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "format start_date harus YYYY-MM-DD",
+			})
+			return
+		}
+		query = query.Where("i.created_at >= ?", t)
+	}
+	if endDate := c.Query("end_date"); endDate != "" {
+		t, err := time.Parse("2006-01-02", endDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "format end_date harus YYYY-MM-DD",
+			})
+			return
+		}
+		query = query.Where("i.created_at < ?", t.AddDate(0, 0, 1))
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal menghitung data",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	type IncidentListResponse struct {
+		ID         int       `json:"id"`
+		Category   string    `json:"category"`
+		Severity   string    `json:"severity"`
+		Title      string    `json:"title"`
+		Status     string    `json:"status"`
+		Reporter   *string   `json:"reporter"`
+		NamaLokasi *string   `json:"nama_lokasi"`
+		BranchID   int       `json:"branch_id"`
+		CreatedAt  time.Time `json:"created_at"`
+	}
+
+	var data []IncidentListResponse
+
+	if err := query.
+		Select(`
+			i.id,
+			i.category,
+			i.severity,
+			i.title,
+			i.status,
+			u.name AS reporter,
+			mp.nama_lokasi,
+			i.branch_id,
+			i.created_at
+		`).
+		Joins("LEFT JOIN users u ON u.id = i.user_id").
+		Joins("LEFT JOIN master_patroli mp ON mp.id = i.id_patroli").
+		Order("i.created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&data).Error; err != nil {
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil data incident",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Incident berhasil diambil",
+		"data":    data,
+		"metadata": gin.H{
+			"page":       page,
+			"limit":      limit,
+			"total":      total,
+			"total_page": int(math.Ceil(float64(total) / float64(limit))),
+			"has_data":   len(data) > 0,
+		},
+	})
+}
+
+// GetIncidentDetail - GET /api/v1/incidents/:id
+func (h *IncidentHandler) GetIncidentDetail(c *gin.Context) {
+	incident, _, ok := h.loadIncident(c)
+	if !ok {
+		return
+	}
+
+	var reporter struct {
+		Name string
+	}
+	h.DB.Raw(`SELECT name FROM users WHERE id = ?`, incident.UserID).Scan(&reporter)
+
+	var namaLokasi *string
+	if incident.IDPatroli != nil {
+		h.DB.Raw(`SELECT nama_lokasi FROM master_patroli WHERE id = ?`, *incident.IDPatroli).
+			Scan(&namaLokasi)
+	}
+
+	type IncidentCommentResponse struct {
+		ID        int       `json:"id"`
+		UserID    int       `json:"user_id"`
+		UserName  *string   `json:"user_name"`
+		Comment   string    `json:"comment"`
+		StatusTo  *string   `json:"status_to"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	var comments []IncidentCommentResponse
+
+	if err := h.DB.Raw(`
+		SELECT
+			ic.id,
+			ic.user_id,
+			u.name AS user_name,
+			ic.comment,
+			NULLIF(ic.status_to, '') AS status_to,
+			ic.created_at
+		FROM incident_comment ic
+		LEFT JOIN users u ON u.id = ic.user_id
+		WHERE ic.incident_id = ?
+		ORDER BY ic.created_at ASC
+	`, incident.ID).Scan(&comments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil komentar incident",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Detail incident berhasil diambil",
+		"data": gin.H{
+			"incident":    incident,
+			"reporter":    reporter.Name,
+			"nama_lokasi": namaLokasi,
+			"comments":    comments,
+		},
+	})
+}
+
+// AcknowledgeIncident - POST /api/v1/incidents/:id/acknowledge
+func (h *IncidentHandler) AcknowledgeIncident(c *gin.Context) {
+	h.changeIncidentStatus(c, models.IncidentStatusOpen, models.IncidentStatusAcknowledged)
+}
+
+// ResolveIncident - POST /api/v1/incidents/:id/resolve
+func (h *IncidentHandler) ResolveIncident(c *gin.Context) {
+	h.changeIncidentStatus(c, models.IncidentStatusAcknowledged, models.IncidentStatusResolved)
+}
+
+// AddIncidentComment - POST /api/v1/incidents/:id/comments
+func (h *IncidentHandler) AddIncidentComment(c *gin.Context) {
+	incident, user, ok := h.loadIncident(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Comment string `json:"comment" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "comment wajib diisi",
+		})
+		return
+	}
+
+	comment := models.IncidentComment{
+		IncidentID: incident.ID,
+		UserID:     uint(user.ID),
+		Comment:    req.Comment,
+	}
+
+	if err := h.DB.Create(&comment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal menyimpan komentar",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Komentar berhasil disimpan",
+		"data":    comment,
+	})
+}
+
+// changeIncidentStatus memindahkan status incident sesuai alur open → acknowledged → resolved
+func (h *IncidentHandler) changeIncidentStatus(c *gin.Context, from, to string) {
+	incident, user, ok := h.loadIncident(c)
+	if !ok {
+		return
+	}
+
+	if !user.IsSupervisor() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"message": "Hanya koordinator yang dapat mengubah status incident",
+		})
+		return
+	}
+
+	var req struct {
+		Comment string `json:"comment"`
+	}
+	_ = c.ShouldBindJSON(&req)
+
+	if incident.Status != from {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"message": "Incident dengan status " + incident.Status + " tidak bisa diubah menjadi " + to,
+		})
+		return
+	}
+
+	now := time.Now()
+	userID := uint(user.ID)
+
+	updates := map[string]interface{}{
+		"status":     to,
+		"updated_at": now,
+	}
+
+	if to == models.IncidentStatusAcknowledged {
+		updates["acknowledged_by"] = userID
+		updates["acknowledged_at"] = now
+	} else {
+		updates["resolved_by"] = userID
+		updates["resolved_at"] = now
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		// kondisi status ikut di WHERE supaya dua supervisor tidak mengubah bersamaan
+		result := tx.Model(&models.Incident{}).
+			Where("id = ? AND status = ?", incident.ID, from).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errIncidentStatusChanged
+		}
+
+		return tx.Create(&models.IncidentComment{
+			IncidentID: incident.ID,
+			UserID:     userID,
+			Comment:    req.Comment,
+			StatusTo:   to,
+		}).Error
+	})
+
+	if errors.Is(err, errIncidentStatusChanged) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengubah status incident",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Status incident berhasil diubah",
+		"data": gin.H{
+			"id":         incident.ID,
+			"status":     to,
+			"updated_by": user.ID,
+			"updated_at": now,
+		},
+	})
+}
+
+// loadIncident mengambil incident dari path :id dan memastikan user punya akses ke branch-nya
+func (h *IncidentHandler) loadIncident(c *gin.Context) (models.Incident, middleware.AuthUser, bool) {
+	var incident models.Incident
+
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  "error",
+			"message": "Unauthorized",
+		})
+		return incident, user, false
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "id incident tidak valid",
+		})
+		return incident, user, false
+	}
+
+	err = h.DB.Where("id = ? AND deleted_at IS NULL", id).First(&incident).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && !user.CanAccessBranch(int(incident.BranchID))) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Incident tidak ditemukan",
+		})
+		return incident, user, false
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil incident",
+			"error":   err.Error(),
+		})
+		return incident, user, false
+	}
+
+	return incident, user, true
+}
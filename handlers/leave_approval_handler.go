@@ -0,0 +1,557 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"api_patroliku_docker/middleware"
+	"api_patroliku_docker/models"
+	"api_patroliku_docker/notification"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var errLeaveStatusChanged = errors.New("status leave sudah diubah oleh user lain")
+
+// validLeaveApproverRoles peran yang boleh dipakai di rantai approval
+var validLeaveApproverRoles = map[string]bool{
+	models.LeaveApproverCoordinator: true,
+	models.LeaveApproverClient:      true,
+	models.LeaveApproverAdmin:       true,
+}
+
+// ApproveLeave - POST /api/v1/leave/:id/approve
+func (h *LeaveHandler) ApproveLeave(c *gin.Context) {
+	h.decideLeave(c, models.LeaveActionApproved)
+}
+
+// RejectLeave - POST /api/v1/leave/:id/reject
+func (h *LeaveHandler) RejectLeave(c *gin.Context) {
+	h.decideLeave(c, models.LeaveActionRejected)
+}
+
+// decideLeave memproses keputusan approver pada step yang sedang berjalan
+func (h *LeaveHandler) decideLeave(c *gin.Context, action string) {
+	leave, user, ok := h.loadLeave(c)
+	if !ok {
+		return
+	}
+
+	var req models.LeaveDecisionRequest
+	_ = c.ShouldBindJSON(&req)
+
+	if action == models.LeaveActionRejected && req.Note == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "note wajib diisi saat menolak leave",
+		})
+		return
+	}
+
+	if leave.Status != models.LeaveStatusPending {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"message": "Leave sudah " + models.LeaveStatusLabel[leave.Status] + ", tidak bisa diproses lagi",
+		})
+		return
+	}
+
+	chain, err := h.leaveChain(leave)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil rantai approval",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	step := leave.ApprovalStep
+	if step < 1 {
+		step = 1
+	}
+	if step > len(chain) {
+		step = len(chain)
+	}
+	role := chain[step-1]
+
+	if !canApproveLeaveStep(user, leave, role) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"message": "Anda bukan approver untuk step ini (" + role + ")",
+		})
+		return
+	}
+
+	// ===== status & step berikutnya =====
+	newStatus := leave.Status
+	newStep := step
+	switch {
+	case action == models.LeaveActionRejected:
+		newStatus = models.LeaveStatusRejected
+	case step >= len(chain):
+		newStatus = models.LeaveStatusApproved
+	default:
+		newStep = step + 1
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Leave{}).
+			Where("id = ? AND status = ? AND approval_step = ?", leave.ID, models.LeaveStatusPending, leave.ApprovalStep).
+			Updates(map[string]interface{}{
+				"status":        newStatus,
+				"approval_step": newStep,
+				"note_approval": req.Note,
+				"updated_at":    time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errLeaveStatusChanged
+		}
+
+		// saldo dipotong hanya saat approval final
+		if newStatus == models.LeaveStatusApproved {
+			deducted, err := deductLeaveBalance(tx, leave)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&models.Leave{}).
+				Where("id = ?", leave.ID).
+				Update("deducted_days", deducted).Error; err != nil {
+				return err
+			}
+
+			// hari leave tercatat sebagai Sakit / Izin di attendance
+			if _, err := applyLeaveAttendance(tx, leave); err != nil {
+				return err
+			}
+		}
+
+		return tx.Create(&models.LeaveApproval{
+			LeaveID:      leave.ID,
+			Step:         step,
+			ApproverRole: role,
+			ApproverID:   uint(user.ID),
+			Action:       action,
+			Note:         req.Note,
+		}).Error
+	})
+
+	if errors.Is(err, errLeaveStatusChanged) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal memproses leave",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// ===== notifikasi =====
+	leave.Status = newStatus
+	leave.ApprovalStep = newStep
+	if newStatus == models.LeaveStatusPending {
+		h.notifyLeaveApprovers(leave, chain[newStep-1])
+	} else {
+		h.notifyLeaveRequester(leave, req.Note)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Leave berhasil di-" + action,
+		"data": gin.H{
+			"id":            leave.ID,
+			"status":        models.LeaveStatusLabel[newStatus],
+			"approval_step": newStep,
+			"total_steps":   len(chain),
+			"approved_by":   user.ID,
+			"note_approval": req.Note,
+			"decided_at":    time.Now(),
+		},
+	})
+}
+
+// CancelLeave - POST /api/v1/leave/:id/cancel
+// Hanya pemohon, selama masih pending atau sudah approved tapi belum dimulai. Saldo dikembalikan.
+func (h *LeaveHandler) CancelLeave(c *gin.Context) {
+	leave, user, ok := h.loadLeave(c)
+	if !ok {
+		return
+	}
+
+	if int(leave.UserTadID) != user.ID {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"message": "Hanya pemohon yang dapat membatalkan leave",
+		})
+		return
+	}
+
+	if !canCancelLeave(leave, time.Now()) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"message": "Leave sudah " + models.LeaveStatusLabel[leave.Status] + ", tidak bisa dibatalkan",
+		})
+		return
+	}
+
+	var req models.LeaveDecisionRequest
+	_ = c.ShouldBindJSON(&req)
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Leave{}).
+			Where("id = ? AND status = ?", leave.ID, leave.Status).
+			Updates(map[string]interface{}{
+				"status":        models.LeaveStatusCancelled,
+				"deducted_days": 0,
+				"updated_at":    time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errLeaveStatusChanged
+		}
+
+		if err := restoreLeaveBalance(tx, leave); err != nil {
+			return err
+		}
+
+		if err := removeLeaveAttendance(tx, leave.ID); err != nil {
+			return err
+		}
+
+		return tx.Create(&models.LeaveApproval{
+			LeaveID:    leave.ID,
+			Step:       leave.ApprovalStep,
+			ApproverID: uint(user.ID),
+			Action:     models.LeaveActionCancelled,
+			Note:       req.Note,
+		}).Error
+	})
+
+	if errors.Is(err, errLeaveStatusChanged) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal membatalkan leave",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Leave berhasil dibatalkan",
+		"data": gin.H{
+			"id":            leave.ID,
+			"status":        models.LeaveStatusLabel[models.LeaveStatusCancelled],
+			"restored_days": leave.DeductedDays,
+		},
+	})
+}
+
+// GetApprovalChain - GET /api/v1/leave/approval-chain?company_id=1
+func (h *LeaveHandler) GetApprovalChain(c *gin.Context) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  "error",
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	companyID, err := strconv.Atoi(c.Query("company_id"))
+	if err != nil || companyID <= 0 {
+		ubc, err := h.userBranchCompany(uint(user.ID))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"message": "Gagal mengambil data company & branch",
+				"error":   err.Error(),
+			})
+			return
+		}
+		companyID = int(ubc.CompanyID)
+	}
+
+	chain, err := h.approvalChain(uint(companyID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil rantai approval",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Rantai approval berhasil diambil",
+		"data": gin.H{
+			"company_id": companyID,
+			"steps":      chain,
+		},
+	})
+}
+
+// SetApprovalChain - PUT /api/v1/leave/approval-chain (admin)
+func (h *LeaveHandler) SetApprovalChain(c *gin.Context) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok || !user.IsAdmin() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"message": "Hanya admin yang dapat mengubah rantai approval",
+		})
+		return
+	}
+
+	var req models.LeaveApprovalChainRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Data request tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	for _, role := range req.Steps {
+		if !validLeaveApproverRoles[role] {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "approver tidak valid: " + role + " (coordinator, client, admin)",
+			})
+			return
+		}
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("company_id = ?", req.CompanyID).
+			Delete(&models.LeaveApprovalChain{}).Error; err != nil {
+			return err
+		}
+
+		rows := make([]models.LeaveApprovalChain, 0, len(req.Steps))
+		for i, role := range req.Steps {
+			rows = append(rows, models.LeaveApprovalChain{
+				CompanyID:    req.CompanyID,
+				StepOrder:    i + 1,
+				ApproverRole: role,
+			})
+		}
+
+		return tx.Create(&rows).Error
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal menyimpan rantai approval",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Rantai approval berhasil disimpan",
+		"data": gin.H{
+			"company_id": req.CompanyID,
+			"steps":      req.Steps,
+		},
+	})
+}
+
+// approvalChain urutan approver untuk company, fallback ke DefaultLeaveApprovalChain
+func (h *LeaveHandler) approvalChain(companyID uint) ([]string, error) {
+	var rows []models.LeaveApprovalChain
+	if err := h.DB.Where("company_id = ?", companyID).
+		Order("step_order ASC").
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return models.DefaultLeaveApprovalChain, nil
+	}
+
+	chain := make([]string, 0, len(rows))
+	for _, row := range rows {
+		chain = append(chain, row.ApproverRole)
+	}
+
+	return chain, nil
+}
+
+// leaveChain rantai approval untuk leave tertentu. Leave yang melebihi saldo
+// (special approval) mendapat step admin tambahan di akhir.
+func (h *LeaveHandler) leaveChain(leave models.Leave) ([]string, error) {
+	chain, err := h.approvalChain(leave.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if leave.SpecialApproval && chain[len(chain)-1] != models.LeaveApproverAdmin {
+		chain = append(append([]string{}, chain...), models.LeaveApproverAdmin)
+	}
+
+	return chain, nil
+}
+
+// canCancelLeave leave pending selalu bisa dibatalkan, leave approved hanya sebelum tanggal mulai
+func canCancelLeave(leave models.Leave, now time.Time) bool {
+	switch leave.Status {
+	case models.LeaveStatusPending:
+		return true
+	case models.LeaveStatusApproved:
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		return leave.DateStart.After(today)
+	}
+	return false
+}
+
+// userBranchCompany mengambil company & branch user dari user_tad_information
+func (h *LeaveHandler) userBranchCompany(userID uint) (UserBranchCompany, error) {
+	var ubc UserBranchCompany
+
+	err := h.DB.Raw(`
+		SELECT
+			c.id AS company_id,
+			b.id AS branch_id
+		FROM user_tad_information uti
+		LEFT JOIN branch b ON b.id = uti.branch_id
+		LEFT JOIN company c ON c.id = b.company_id
+		WHERE uti.user_id = ?
+	`, userID).Scan(&ubc).Error
+
+	return ubc, err
+}
+
+// canApproveLeaveStep cek apakah user adalah approver untuk peran di step berjalan.
+// Jika leave sudah menunjuk approver tertentu, hanya user tersebut yang boleh memproses.
+func canApproveLeaveStep(user middleware.AuthUser, leave models.Leave, role string) bool {
+	switch role {
+	case models.LeaveApproverCoordinator:
+		if leave.UserCoordinatorID != 0 {
+			return user.ID == int(leave.UserCoordinatorID)
+		}
+		return user.IsCoordinator() && user.BranchID == int(leave.BranchID)
+	case models.LeaveApproverClient:
+		if leave.UserClientID != 0 {
+			return user.ID == int(leave.UserClientID)
+		}
+		return user.IsClient() && user.BranchID == int(leave.BranchID)
+	case models.LeaveApproverAdmin:
+		return user.IsAdmin()
+	}
+	return false
+}
+
+// leaveApproverIDs user yang perlu dikabari untuk step dengan peran tertentu
+func (h *LeaveHandler) leaveApproverIDs(leave models.Leave, role string) ([]int, error) {
+	switch role {
+	case models.LeaveApproverCoordinator:
+		if leave.UserCoordinatorID != 0 {
+			return []int{int(leave.UserCoordinatorID)}, nil
+		}
+		return notification.BranchUsersByType(h.DB, int(leave.BranchID), middleware.CoordinatorUserTypes)
+	case models.LeaveApproverClient:
+		if leave.UserClientID != 0 {
+			return []int{int(leave.UserClientID)}, nil
+		}
+		return notification.BranchUsersByType(h.DB, int(leave.BranchID), middleware.ClientUserTypes)
+	case models.LeaveApproverAdmin:
+		return notification.UsersByType(h.DB, middleware.AdminUserTypes)
+	}
+	return nil, nil
+}
+
+// notifyLeaveApprovers mengabari approver step berikutnya
+func (h *LeaveHandler) notifyLeaveApprovers(leave models.Leave, role string) {
+	recipients, err := h.leaveApproverIDs(leave, role)
+	if err == nil {
+		err = notification.Send(h.DB, recipients, "leave_approval",
+			"Pengajuan leave menunggu approval",
+			"Ada pengajuan "+leave.TypeLeave+" yang menunggu persetujuan Anda",
+			models.JSONMap{"leave_id": leave.ID, "approval_step": leave.ApprovalStep})
+	}
+	if err != nil {
+		log.Printf("⚠️ Gagal mengirim notifikasi approval leave %d: %v", leave.ID, err)
+	}
+}
+
+// notifyLeaveRequester mengabari pemohon hasil akhir pengajuan
+func (h *LeaveHandler) notifyLeaveRequester(leave models.Leave, note string) {
+	label := models.LeaveStatusLabel[leave.Status]
+	if err := notification.Send(h.DB, []int{int(leave.UserTadID)}, "leave_decision",
+		"Pengajuan leave "+label,
+		"Pengajuan "+leave.TypeLeave+" Anda "+label+". "+note,
+		models.JSONMap{"leave_id": leave.ID, "status": label}); err != nil {
+		log.Printf("⚠️ Gagal mengirim notifikasi keputusan leave %d: %v", leave.ID, err)
+	}
+}
+
+// loadLeave mengambil leave dari path :id
+func (h *LeaveHandler) loadLeave(c *gin.Context) (models.Leave, middleware.AuthUser, bool) {
+	var leave models.Leave
+
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  "error",
+			"message": "Unauthorized",
+		})
+		return leave, user, false
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "id leave tidak valid",
+		})
+		return leave, user, false
+	}
+
+	err = h.DB.Where("id = ? AND deleted_at IS NULL", id).First(&leave).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Leave tidak ditemukan",
+		})
+		return leave, user, false
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil leave",
+			"error":   err.Error(),
+		})
+		return leave, user, false
+	}
+
+	return leave, user, true
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"api_patroliku_docker/models"
+	"api_patroliku_docker/schedule"
+
+	"gorm.io/gorm"
+)
+
+// errAttendanceOnLeave check-in / check-out di hari leave yang sudah disetujui
+var errAttendanceOnLeave = errors.New("anda sedang cuti hari ini, batalkan leave untuk bekerja")
+
+// isLeaveAttendance attendance dibuat dari leave dan belum pernah check-in
+func isLeaveAttendance(attendance models.UserAttendance) bool {
+	return attendance.LeaveID != nil && attendance.CheckIn == nil
+}
+
+// leaveAttendanceStatus status attendance untuk hari leave: Sakit untuk jenis sakit, selain itu Izin
+func leaveAttendanceStatus(leave models.Leave) int {
+	if strings.Contains(strings.ToLower(leave.TypeLeave), "sakit") {
+		return models.AttendanceStatusSakit
+	}
+	return models.AttendanceStatusIzin
+}
+
+// applyLeaveAttendance membuat attendance untuk setiap hari terjadwal yang tercakup leave.
+// Hari yang sudah punya attendance (mis. sudah check-in) tidak diubah. Dijalankan di dalam transaksi.
+func applyLeaveAttendance(tx *gorm.DB, leave models.Leave) (int, error) {
+	shifts, err := schedule.Shifts(tx, leave.UserTadID, leave.DateStart, leave.DateEnd)
+	if err != nil {
+		return 0, err
+	}
+
+	status := leaveAttendanceStatus(leave)
+	leaveID := leave.ID
+	created := 0
+	seen := map[string]bool{}
+
+	for _, shift := range shifts {
+		if seen[shift.Date] {
+			continue
+		}
+		seen[shift.Date] = true
+
+		date, err := time.Parse("2006-01-02", shift.Date)
+		if err != nil {
+			return created, err
+		}
+
+		var existing models.UserAttendance
+		err = tx.Where("users_id = ? AND date_attendence = ?", leave.UserTadID, date).
+			First(&existing).Error
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return created, err
+		}
+
+		scheduleID := uint(shift.ScheduleID)
+		attendance := models.UserAttendance{
+			UserID:           leave.UserTadID,
+			ScheduleID:       &scheduleID,
+			AttendanceStatus: status,
+			DateAttendance:   date,
+			LeaveID:          &leaveID,
+		}
+		if err := tx.Create(&attendance).Error; err != nil {
+			return created, err
+		}
+		created++
+	}
+
+	return created, nil
+}
+
+// removeLeaveAttendance menghapus attendance hasil leave yang dibatalkan, kecuali yang sudah check-in
+func removeLeaveAttendance(tx *gorm.DB, leaveID uint) error {
+	return tx.Where("leave_id = ? AND check_in IS NULL", leaveID).
+		Delete(&models.UserAttendance{}).Error
+}
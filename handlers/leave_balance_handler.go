@@ -0,0 +1,416 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"api_patroliku_docker/middleware"
+	"api_patroliku_docker/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errLeaveBalanceExceeded = errors.New("sisa saldo leave tidak mencukupi")
+
+// GetLeaveBalance - GET /api/v1/leave/balance?user_tad_id=&year=
+func (h *LeaveHandler) GetLeaveBalance(c *gin.Context) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  "error",
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	userID := user.ID
+	if raw := c.Query("user_tad_id"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil || id <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "user_tad_id tidak valid",
+			})
+			return
+		}
+		userID = id
+	}
+
+	year := time.Now().Year()
+	if raw := c.Query("year"); raw != "" {
+		y, err := strconv.Atoi(raw)
+		if err != nil || y < 2000 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "year tidak valid",
+			})
+			return
+		}
+		year = y
+	}
+
+	ubc, err := h.userBranchCompany(uint(userID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil data company & branch",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// saldo user lain hanya untuk supervisor di branch yang sama atau admin
+	if userID != user.ID && !(user.IsSupervisor() && user.CanAccessBranch(int(ubc.BranchID))) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"message": "Tidak memiliki akses ke saldo leave user ini",
+		})
+		return
+	}
+
+	var quotas []models.LeaveQuota
+	if err := h.DB.Where("company_id = ?", ubc.CompanyID).
+		Order("leave_type_id ASC").
+		Find(&quotas).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil jatah leave",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	data := make([]gin.H, 0, len(quotas))
+	for _, quota := range quotas {
+		balance, err := leaveBalance(h.DB, uint(userID), quota, year)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"message": "Gagal mengambil saldo leave",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		pending, err := pendingLeaveDays(h.DB, uint(userID), quota.LeaveTypeID, year, 0)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"message": "Gagal menghitung leave pending",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		data = append(data, gin.H{
+			"leave_type_id": quota.LeaveTypeID,
+			"year":          year,
+			"entitled":      balance.Entitled,
+			"carried_over":  balance.CarriedOver,
+			"used":          balance.Used,
+			"pending":       pending,
+			"remaining":     balance.Remaining(),
+			"available":     balance.Remaining() - pending,
+			"exceed_policy": quota.ExceedPolicy,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Saldo leave berhasil diambil",
+		"data": gin.H{
+			"user_tad_id": userID,
+			"company_id":  ubc.CompanyID,
+			"year":        year,
+			"balances":    data,
+		},
+	})
+}
+
+// GetLeaveQuota - GET /api/v1/leave/quota?company_id=1
+func (h *LeaveHandler) GetLeaveQuota(c *gin.Context) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  "error",
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	companyID, err := strconv.Atoi(c.Query("company_id"))
+	if err != nil || companyID <= 0 {
+		ubc, err := h.userBranchCompany(uint(user.ID))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"message": "Gagal mengambil data company & branch",
+				"error":   err.Error(),
+			})
+			return
+		}
+		companyID = int(ubc.CompanyID)
+	}
+
+	var quotas []models.LeaveQuota
+	if err := h.DB.Where("company_id = ?", companyID).
+		Order("leave_type_id ASC").
+		Find(&quotas).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil jatah leave",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Jatah leave berhasil diambil",
+		"data":    quotas,
+	})
+}
+
+// SetLeaveQuota - PUT /api/v1/leave/quota (admin)
+func (h *LeaveHandler) SetLeaveQuota(c *gin.Context) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok || !user.IsAdmin() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"message": "Hanya admin yang dapat mengubah jatah leave",
+		})
+		return
+	}
+
+	var req models.LeaveQuotaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Data request tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if req.ExceedPolicy == "" {
+		req.ExceedPolicy = models.LeaveExceedReject
+	}
+	if req.ExceedPolicy != models.LeaveExceedReject && req.ExceedPolicy != models.LeaveExceedSpecialApproval {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "exceed_policy tidak valid (reject, special_approval)",
+		})
+		return
+	}
+
+	quota := models.LeaveQuota{
+		CompanyID:    req.CompanyID,
+		LeaveTypeID:  req.LeaveTypeID,
+		AnnualDays:   req.AnnualDays,
+		MaxCarryOver: req.MaxCarryOver,
+		ExceedPolicy: req.ExceedPolicy,
+	}
+
+	if err := h.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "company_id"}, {Name: "leave_type_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"annual_days", "max_carry_over", "exceed_policy", "updated_at"}),
+	}).Create(&quota).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal menyimpan jatah leave",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Jatah leave berhasil disimpan",
+		"data":    quota,
+	})
+}
+
+// leaveQuota jatah leave untuk company & jenis leave, nil jika tidak diatur (tanpa batas)
+func leaveQuota(db *gorm.DB, companyID, leaveTypeID uint) (*models.LeaveQuota, error) {
+	var quota models.LeaveQuota
+	err := db.Where("company_id = ? AND leave_type_id = ?", companyID, leaveTypeID).
+		First(&quota).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &quota, nil
+}
+
+// leaveBalance saldo tahun tertentu tanpa menulis ke database. Jika baris saldo belum ada,
+// saldo dihitung dari jatah dengan sisa tahun sebelumnya dibawa maksimal sebesar MaxCarryOver.
+func leaveBalance(db *gorm.DB, userID uint, quota models.LeaveQuota, year int) (models.LeaveBalance, error) {
+	var balance models.LeaveBalance
+
+	err := db.Where("user_id = ? AND leave_type_id = ? AND year = ?", userID, quota.LeaveTypeID, year).
+		First(&balance).Error
+	if err == nil {
+		return balance, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return balance, err
+	}
+
+	carriedOver := 0
+	var previous models.LeaveBalance
+	err = db.Where("user_id = ? AND leave_type_id = ? AND year = ?", userID, quota.LeaveTypeID, year-1).
+		First(&previous).Error
+	if err == nil {
+		carriedOver = min(max(previous.Remaining(), 0), quota.MaxCarryOver)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return balance, err
+	}
+
+	return models.LeaveBalance{
+		UserID:      userID,
+		LeaveTypeID: quota.LeaveTypeID,
+		Year:        year,
+		Entitled:    quota.AnnualDays,
+		CarriedOver: carriedOver,
+	}, nil
+}
+
+// ensureLeaveBalance seperti leaveBalance, baris saldo dibuat jika belum ada.
+// Hanya dipanggil saat saldo dipotong.
+func ensureLeaveBalance(db *gorm.DB, userID uint, quota models.LeaveQuota, year int) (models.LeaveBalance, error) {
+	balance, err := leaveBalance(db, userID, quota, year)
+	if err != nil || balance.ID != 0 {
+		return balance, err
+	}
+
+	// request paralel bisa membuat baris yang sama, ambil ulang setelah insert
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&balance).Error; err != nil {
+		return balance, err
+	}
+
+	err = db.Where("user_id = ? AND leave_type_id = ? AND year = ?", userID, quota.LeaveTypeID, year).
+		First(&balance).Error
+	return balance, err
+}
+
+// leaveYearDays pembagian total hari leave per tahun. Leave yang melewati pergantian tahun
+// dipotong dari saldo tahun date_start dan tahun date_end sebesar DaysNextYear.
+func leaveYearDays(leave models.Leave, total int) map[int]int {
+	nextYear := min(leave.DaysNextYear, total)
+	days := map[int]int{leave.DateStart.Year(): total - nextYear}
+	if nextYear > 0 {
+		days[leave.DateEnd.Year()] += nextYear
+	}
+	return days
+}
+
+// pendingLeaveDays total hari leave yang masih menunggu approval pada tahun tersebut,
+// untuk leave yang melewati pergantian tahun hanya bagian di tahun tersebut
+func pendingLeaveDays(db *gorm.DB, userID, leaveTypeID uint, year int, excludeLeaveID uint) (int, error) {
+	var total int64
+	err := db.Model(&models.Leave{}).
+		Select("COALESCE(SUM(CASE WHEN EXTRACT(YEAR FROM date_start) = ? THEN days - days_next_year ELSE days_next_year END), 0)", year).
+		Where("user_tad_id = ? AND leave_type_id = ? AND status = ? AND deleted_at IS NULL", userID, leaveTypeID, models.LeaveStatusPending).
+		Where("EXTRACT(YEAR FROM date_start) = ? OR EXTRACT(YEAR FROM date_end) = ?", year, year).
+		Where("id <> ?", excludeLeaveID).
+		Scan(&total).Error
+	return int(total), err
+}
+
+// checkLeaveBalance menentukan apakah pengajuan baru muat di saldo setiap tahun yang dilewati.
+// Mengembalikan specialApproval=true jika melebihi saldo dan kebijakan company mengizinkan.
+func checkLeaveBalance(db *gorm.DB, leave models.Leave) (specialApproval bool, err error) {
+	if leave.Days <= 0 || leave.DateStart.IsZero() {
+		return false, nil
+	}
+
+	quota, err := leaveQuota(db, leave.CompanyID, leave.LeaveTypeID)
+	if err != nil || quota == nil {
+		return false, err
+	}
+
+	exceeded := false
+	for year, days := range leaveYearDays(leave, leave.Days) {
+		balance, err := leaveBalance(db, leave.UserTadID, *quota, year)
+		if err != nil {
+			return false, err
+		}
+
+		pending, err := pendingLeaveDays(db, leave.UserTadID, leave.LeaveTypeID, year, leave.ID)
+		if err != nil {
+			return false, err
+		}
+
+		if days > balance.Remaining()-pending {
+			exceeded = true
+		}
+	}
+
+	if !exceeded {
+		return false, nil
+	}
+
+	if quota.ExceedPolicy == models.LeaveExceedSpecialApproval {
+		return true, nil
+	}
+
+	return false, errLeaveBalanceExceeded
+}
+
+// deductLeaveBalance memotong saldo saat leave disetujui final, dijalankan di dalam transaksi.
+// Mengembalikan jumlah hari yang dipotong (0 jika jenis leave tidak punya jatah).
+func deductLeaveBalance(tx *gorm.DB, leave models.Leave) (int, error) {
+	if leave.Days <= 0 || leave.DateStart.IsZero() {
+		return 0, nil
+	}
+
+	quota, err := leaveQuota(tx, leave.CompanyID, leave.LeaveTypeID)
+	if err != nil || quota == nil {
+		return 0, err
+	}
+
+	for year, days := range leaveYearDays(leave, leave.Days) {
+		if days == 0 {
+			continue
+		}
+
+		balance, err := ensureLeaveBalance(tx, leave.UserTadID, *quota, year)
+		if err != nil {
+			return 0, err
+		}
+
+		if err := tx.Model(&models.LeaveBalance{}).
+			Where("id = ?", balance.ID).
+			Update("used", gorm.Expr("used + ?", days)).Error; err != nil {
+			return 0, err
+		}
+	}
+
+	return leave.Days, nil
+}
+
+// restoreLeaveBalance mengembalikan saldo yang dipotong saat leave dibatalkan
+func restoreLeaveBalance(tx *gorm.DB, leave models.Leave) error {
+	if leave.DeductedDays <= 0 {
+		return nil
+	}
+
+	for year, days := range leaveYearDays(leave, leave.DeductedDays) {
+		if days == 0 {
+			continue
+		}
+
+		if err := tx.Model(&models.LeaveBalance{}).
+			Where("user_id = ? AND leave_type_id = ? AND year = ?", leave.UserTadID, leave.LeaveTypeID, year).
+			Update("used", gorm.Expr("GREATEST(used - ?, 0)", days)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"api_patroliku_docker/models"
+	"api_patroliku_docker/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxLeaveDocuments jumlah maksimal file dokumen per pengajuan leave
+const maxLeaveDocuments = 5
+
+// leaveDocumentFiles mengambil file dari field multipart "documents[]" / "documents".
+// Hanya foto atau PDF, masing-masing maksimal storage.MaxDocumentSize.
+func leaveDocumentFiles(c *gin.Context) ([]*multipart.FileHeader, error) {
+	if !strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		return nil, nil
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, err
+	}
+
+	files := append(form.File["documents[]"], form.File["documents"]...)
+
+	if len(files) > maxLeaveDocuments {
+		return nil, fmt.Errorf("maksimal %d dokumen", maxLeaveDocuments)
+	}
+
+	for _, file := range files {
+		if !storage.IsImage(file) && !storage.IsDocument(file) {
+			return nil, fmt.Errorf("file %s harus berupa foto atau PDF", file.Filename)
+		}
+		if file.Size > storage.MaxDocumentSize {
+			return nil, fmt.Errorf("ukuran file %s maksimal %d MB", file.Filename, storage.MaxDocumentSize>>20)
+		}
+	}
+
+	return files, nil
+}
+
+// saveLeaveDocuments menyimpan dokumen leave ke folder privat.
+// Jika salah satu gagal, file yang sudah tersimpan dihapus lagi.
+func saveLeaveDocuments(c *gin.Context, files []*multipart.FileHeader, userID uint) ([]interface{}, error) {
+	saved := make([]interface{}, 0, len(files))
+	paths := make([]string, 0, len(files))
+
+	for _, file := range files {
+		path, err := storage.SavePrivateFile(c, file, "leave/"+strconv.Itoa(int(userID)))
+		if err != nil {
+			removeLeaveDocuments(paths)
+			return nil, err
+		}
+		paths = append(paths, path)
+
+		saved = append(saved, map[string]interface{}{
+			"name":         file.Filename,
+			"path":         path,
+			"content_type": file.Header.Get("Content-Type"),
+			"size":         file.Size,
+			"uploaded_at":  time.Now(),
+		})
+	}
+
+	return saved, nil
+}
+
+// removeLeaveDocuments rollback file dokumen yang sudah tersimpan
+func removeLeaveDocuments(paths []string) {
+	for _, path := range paths {
+		_ = storage.RemovePrivateFile(path)
+	}
+}
+
+// leaveDocumentPaths path file dari kolom documents
+func leaveDocumentPaths(documents models.JSONMap) []string {
+	var paths []string
+	for _, file := range leaveDocumentEntries(documents) {
+		if path, ok := file["path"].(string); ok {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// leaveDocumentEntries daftar file dari documents["files"]
+func leaveDocumentEntries(documents models.JSONMap) []map[string]interface{} {
+	raw, _ := documents["files"].([]interface{})
+
+	entries := make([]map[string]interface{}, 0, len(raw))
+	for _, item := range raw {
+		if entry, ok := item.(map[string]interface{}); ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// leaveDocumentsResponse dokumen untuk response: path di disk diganti URL download yang dicek aksesnya
+func leaveDocumentsResponse(leave models.Leave) gin.H {
+	files := []gin.H{}
+	for i, entry := range leaveDocumentEntries(leave.Documents) {
+		files = append(files, gin.H{
+			"index":        i,
+			"name":         entry["name"],
+			"content_type": entry["content_type"],
+			"size":         entry["size"],
+			"uploaded_at":  entry["uploaded_at"],
+			"download_url": fmt.Sprintf("/api/v1/leave/%d/documents/%d", leave.ID, i),
+		})
+	}
+
+	return gin.H{
+		"document": leave.Documents["document"],
+		"files":    files,
+	}
+}
+
+// DownloadLeaveDocument - GET /api/v1/leave/:id/documents/:index
+func (h *LeaveHandler) DownloadLeaveDocument(c *gin.Context) {
+	leave, user, ok := h.loadLeave(c)
+	if !ok {
+		return
+	}
+
+	if !canViewLeave(user, leave) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Leave tidak ditemukan",
+		})
+		return
+	}
+
+	index, err := strconv.Atoi(c.Param("index"))
+	entries := leaveDocumentEntries(leave.Documents)
+	if err != nil || index < 0 || index >= len(entries) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Dokumen tidak ditemukan",
+		})
+		return
+	}
+
+	entry := entries[index]
+	rel, _ := entry["path"].(string)
+
+	path, err := storage.PrivatePath(rel)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Dokumen tidak ditemukan",
+		})
+		return
+	}
+
+	name, _ := entry["name"].(string)
+	if name == "" {
+		name = filepath.Base(path)
+	}
+
+	c.FileAttachment(path, name)
+}
@@ -1,11 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
 	"api_patroliku_docker/database"
+	"api_patroliku_docker/events"
 	"api_patroliku_docker/models"
+	"api_patroliku_docker/schedule"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -23,7 +26,8 @@ func NewLeaveHandler() *LeaveHandler {
 func (h *LeaveHandler) SaveLeave(c *gin.Context) {
 	var req models.LeaveSaveRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil {
+	// JSON atau multipart/form-data (jika melampirkan dokumen)
+	if err := c.ShouldBind(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"status":  "error",
 			"message": "Data request tidak valid",
@@ -32,19 +36,18 @@ func (h *LeaveHandler) SaveLeave(c *gin.Context) {
 		return
 	}
 
-	var ubc UserBranchCompany
-
-	sql := `
-		SELECT 
-			c.id AS company_id,
-			b.id AS branch_id
-		FROM user_tad_information uti
-		LEFT JOIN branch b ON b.id = uti.branch_id
-		LEFT JOIN company c ON c.id = b.company_id
-		WHERE uti.user_id = ?
-	`
+	documentFiles, err := leaveDocumentFiles(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Dokumen tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
 
-	if err := h.DB.Raw(sql, req.UserTadID).Scan(&ubc).Error; err != nil {
+	ubc, err := h.userBranchCompany(req.UserTadID)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
 			"message": "Gagal mengambil data company & branch",
@@ -61,22 +64,26 @@ func (h *LeaveHandler) SaveLeave(c *gin.Context) {
 		return
 	}
 
-	// ===== Parse tanggal (opsional) =====
-	var dateStart time.Time
-	var dateEnd time.Time
+	// ===== Parse & validasi tanggal =====
+	if req.DateStart == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "date_start wajib diisi",
+		})
+		return
+	}
 
-	if req.DateStart != "" {
-		t, err := time.Parse("2006-01-02", req.DateStart)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"status":  "error",
-				"message": "Format date_start harus YYYY-MM-DD",
-			})
-			return
-		}
-		dateStart = t
+	dateStart, err := time.Parse("2006-01-02", req.DateStart)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Format date_start harus YYYY-MM-DD",
+		})
+		return
 	}
 
+	// date_end kosong = leave satu hari
+	dateEnd := dateStart
 	if req.DateEnd != "" {
 		t, err := time.Parse("2006-01-02", req.DateEnd)
 		if err != nil {
@@ -89,11 +96,56 @@ func (h *LeaveHandler) SaveLeave(c *gin.Context) {
 		dateEnd = t
 	}
 
-	// ===== Documents (opsional) =====
-	var documents models.JSONMap
-	if req.Document != "" {
-		documents = make(models.JSONMap)
-		documents["document"] = req.Document
+	if dateEnd.Before(dateStart) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "date_end tidak boleh sebelum date_start",
+		})
+		return
+	}
+
+	if int(dateEnd.Sub(dateStart).Hours()/24)+1 > schedule.MaxRangeDays {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Rentang leave terlalu panjang",
+		})
+		return
+	}
+
+	overlapID, err := overlappingLeaveID(h.DB, req.UserTadID, dateStart, dateEnd, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengecek leave lain",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if overlapID != 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":   "error",
+			"message":  "Tanggal leave bertabrakan dengan pengajuan lain",
+			"leave_id": overlapID,
+		})
+		return
+	}
+
+	// ===== Shift yang ditinggalkan =====
+	shifts, err := schedule.Shifts(h.DB, req.UserTadID, dateStart, dateEnd)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil jadwal",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if len(shifts) == 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"status":  "error",
+			"message": "Tidak ada jadwal kerja pada rentang tanggal tersebut",
+		})
+		return
 	}
 
 	leave := models.Leave{
@@ -106,15 +158,57 @@ func (h *LeaveHandler) SaveLeave(c *gin.Context) {
 		UserCoordinatorID:  req.UserCoordinatorID,
 		TypeLeave:          req.TypeLeave,
 		Code:               req.Code,
-		Documents:          documents,
 		DateRequest:        time.Now(),
 		DateStart:          dateStart,
 		DateEnd:            dateEnd,
 		Note:               req.Note,
-		Status:             "1", // 1 = Pending
+		Status:             models.LeaveStatusPending,
+		ApprovalStep:       1,
+	}
+	leave.Days, leave.DaysNextYear = countLeaveDays(shifts, dateStart.Year())
+
+	// ===== Cek saldo leave =====
+	specialApproval, err := checkLeaveBalance(h.DB, leave)
+	if errors.Is(err, errLeaveBalanceExceeded) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengecek saldo leave",
+			"error":   err.Error(),
+		})
+		return
+	}
+	leave.SpecialApproval = specialApproval
+
+	// ===== Documents (opsional) =====
+	if req.Document != "" || len(documentFiles) > 0 {
+		leave.Documents = make(models.JSONMap)
+	}
+	if req.Document != "" {
+		leave.Documents["document"] = req.Document
+	}
+	if len(documentFiles) > 0 {
+		files, err := saveLeaveDocuments(c, documentFiles, req.UserTadID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"message": "Gagal menyimpan dokumen",
+				"error":   err.Error(),
+			})
+			return
+		}
+		leave.Documents["files"] = files
 	}
 
 	if err := h.DB.Create(&leave).Error; err != nil {
+		removeLeaveDocuments(leaveDocumentPaths(leave.Documents))
+
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
 			"message": "Gagal menyimpan leave",
@@ -123,17 +217,37 @@ func (h *LeaveHandler) SaveLeave(c *gin.Context) {
 		return
 	}
 
+	if chain, err := h.leaveChain(leave); err == nil {
+		h.notifyLeaveApprovers(leave, chain[0])
+	}
+
+	events.Publish(events.Event{
+		Type:     events.TypeLeaveSubmitted,
+		BranchID: int(leave.BranchID),
+		UserID:   int(leave.UserTadID),
+		Data: gin.H{
+			"leave_id":   leave.ID,
+			"type_leave": leave.TypeLeave,
+			"date_start": dateStart.Format("2006-01-02"),
+			"date_end":   dateEnd.Format("2006-01-02"),
+		},
+	})
+
 	c.JSON(http.StatusCreated, gin.H{
 		"status":  "success",
 		"message": "Leave berhasil disimpan",
 		"data": gin.H{
-			"id":           leave.ID,
-			"user_tad_id":  leave.UserTadID,
-			"type_leave":   leave.TypeLeave,
-			"date_start":   req.DateStart,
-			"date_end":     req.DateEnd,
-			"status":       "Pending",
-			"date_request": leave.DateRequest,
+			"id":               leave.ID,
+			"user_tad_id":      leave.UserTadID,
+			"type_leave":       leave.TypeLeave,
+			"date_start":       dateStart.Format("2006-01-02"),
+			"date_end":         dateEnd.Format("2006-01-02"),
+			"days":             leave.Days,
+			"shifts":           shifts,
+			"status":           "Pending",
+			"date_request":     leave.DateRequest,
+			"special_approval": leave.SpecialApproval,
+			"documents":        leaveDocumentsResponse(leave),
 		},
 	})
 }
@@ -142,3 +256,37 @@ type UserBranchCompany struct {
 	CompanyID uint `gorm:"column:company_id"`
 	BranchID  uint `gorm:"column:branch_id"`
 }
+
+// countLeaveDays jumlah tanggal berbeda yang memiliki shift, nextYear bagian yang jatuh setelah startYear
+func countLeaveDays(shifts []schedule.Shift, startYear int) (days, nextYear int) {
+	dates := map[string]bool{}
+	for _, shift := range shifts {
+		if dates[shift.Date] {
+			continue
+		}
+		dates[shift.Date] = true
+		if date, err := time.Parse("2006-01-02", shift.Date); err == nil && date.Year() > startYear {
+			nextYear++
+		}
+	}
+	return len(dates), nextYear
+}
+
+// overlappingLeaveID id leave pending/approved milik user yang beririsan dengan rentang, 0 jika tidak ada
+func overlappingLeaveID(db *gorm.DB, userID uint, start, end time.Time, excludeLeaveID uint) (uint, error) {
+	var ids []uint
+
+	err := db.Table("leave_new").
+		Where("user_tad_id = ? AND deleted_at IS NULL", userID).
+		Where("status IN ?", []string{models.LeaveStatusPending, models.LeaveStatusApproved}).
+		Where("DATE(date_start) <= ? AND DATE(date_end) >= ?", end.Format("2006-01-02"), start.Format("2006-01-02")).
+		Where("id <> ?", excludeLeaveID).
+		Order("id ASC").
+		Limit(1).
+		Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+
+	return ids[0], nil
+}
@@ -0,0 +1,392 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"api_patroliku_docker/middleware"
+	"api_patroliku_docker/models"
+	"api_patroliku_docker/schedule"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ListLeave - GET /api/v1/leave
+// Filter: user_tad_id, pending_for_me=true, status, leave_type_id, start_date, end_date, page, limit
+func (h *LeaveHandler) ListLeave(c *gin.Context) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  "error",
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	// ===== pagination =====
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+
+	offset := (page - 1) * limit
+
+	query := h.DB.Table("leave_new l").
+		Joins("LEFT JOIN users u ON u.id = l.user_tad_id").
+		Joins("LEFT JOIN leave_approval_chain lac ON lac.company_id = l.company_id AND lac.step_order = l.approval_step").
+		Where("l.deleted_at IS NULL")
+
+	// ===== batasan sesuai peran =====
+	query = scopeLeaveQuery(query, user)
+
+	// ===== filter =====
+	if userTadID := c.Query("user_tad_id"); userTadID != "" {
+		query = query.Where("l.user_tad_id = ?", userTadID)
+	}
+
+	if c.Query("pending_for_me") == "true" {
+		query = pendingForApproverQuery(query, user)
+	}
+
+	if status := c.Query("status"); status != "" {
+		code, ok := leaveStatusCode(status)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "status tidak valid (pending, approved, rejected, cancelled)",
+			})
+			return
+		}
+		query = query.Where("l.status = ?", code)
+	}
+
+	if leaveTypeID := c.Query("leave_type_id"); leaveTypeID != "" {
+		query = query.Where("l.leave_type_id = ?", leaveTypeID)
+	}
+
+	// leave yang rentangnya beririsan dengan filter tanggal
+	if startDate := c.Query("start_date"); startDate != "" {
+		t, err := time.Parse("2006-01-02", startDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "format start_date harus YYYY-MM-DD",
+			})
+			return
+		}
+		query = query.Where("l.date_end >= ?", t)
+	}
+	if endDate := c.Query("end_date"); endDate != "" {
+		t, err := time.Parse("2006-01-02", endDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "format end_date harus YYYY-MM-DD",
+			})
+			return
+		}
+		query = query.Where("l.date_start <= ?", t)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal menghitung data",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	type LeaveListResponse struct {
+		ID           int       `json:"id"`
+		UserTadID    int       `json:"user_tad_id"`
+		UserName     *string   `json:"user_name"`
+		LeaveTypeID  int       `json:"leave_type_id"`
+		TypeLeave    string    `json:"type_leave"`
+		Code         string    `json:"code"`
+		DateRequest  time.Time `json:"date_request"`
+		DateStart    time.Time `json:"date_start"`
+		DateEnd      time.Time `json:"date_end"`
+		Days         int       `json:"days"`
+		Status       string    `json:"status"`
+		StatusLabel  string    `json:"status_label" gorm:"-"`
+		ApprovalStep int       `json:"approval_step"`
+		CurrentRole  *string   `json:"current_approver_role"`
+	}
+
+	var data []LeaveListResponse
+
+	if err := query.
+		Select(`
+			l.id,
+			l.user_tad_id,
+			u.name AS user_name,
+			l.leave_type_id,
+			l.type_leave,
+			l.code,
+			l.date_request,
+			l.date_start,
+			l.date_end,
+			l.days,
+			l.status,
+			l.approval_step,
+			CASE WHEN l.status = ? THEN `+currentApproverRoleSQL()+` END AS current_role
+		`, models.LeaveStatusPending).
+		Order("l.date_request DESC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&data).Error; err != nil {
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil data leave",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	for i := range data {
+		data[i].StatusLabel = models.LeaveStatusLabel[data[i].Status]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Data leave berhasil diambil",
+		"data":    data,
+		"metadata": gin.H{
+			"page":       page,
+			"limit":      limit,
+			"total":      total,
+			"total_page": int(math.Ceil(float64(total) / float64(limit))),
+			"has_data":   len(data) > 0,
+		},
+	})
+}
+
+// GetLeaveDetail - GET /api/v1/leave/:id beserta timeline approval & dokumen
+func (h *LeaveHandler) GetLeaveDetail(c *gin.Context) {
+	leave, user, ok := h.loadLeave(c)
+	if !ok {
+		return
+	}
+
+	if !canViewLeave(user, leave) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Leave tidak ditemukan",
+		})
+		return
+	}
+
+	var requester struct {
+		Name string
+	}
+	h.DB.Raw(`SELECT name FROM users WHERE id = ?`, leave.UserTadID).Scan(&requester)
+
+	chain, err := h.leaveChain(leave)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil rantai approval",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	type LeaveTimelineEntry struct {
+		Action       string    `json:"action"`
+		Step         int       `json:"step"`
+		ApproverRole string    `json:"approver_role"`
+		UserID       int       `json:"user_id"`
+		UserName     *string   `json:"user_name"`
+		Note         string    `json:"note"`
+		CreatedAt    time.Time `json:"created_at"`
+	}
+
+	var history []LeaveTimelineEntry
+	if err := h.DB.Raw(`
+		SELECT
+			la.action,
+			la.step,
+			la.approver_role,
+			la.approver_id AS user_id,
+			u.name AS user_name,
+			la.note,
+			la.created_at
+		FROM leave_approval la
+		LEFT JOIN users u ON u.id = la.approver_id
+		WHERE la.leave_id = ?
+		ORDER BY la.created_at ASC, la.id ASC
+	`, leave.ID).Scan(&history).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil riwayat approval",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	shifts, err := schedule.Shifts(h.DB, leave.UserTadID, leave.DateStart, leave.DateEnd)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil jadwal",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// ===== timeline: diajukan → keputusan tiap step → step yang masih ditunggu =====
+	requesterName := requester.Name
+	timeline := []LeaveTimelineEntry{{
+		Action:    "submitted",
+		UserID:    int(leave.UserTadID),
+		UserName:  &requesterName,
+		Note:      leave.Note,
+		CreatedAt: leave.DateRequest,
+	}}
+	timeline = append(timeline, history...)
+
+	var waitingFor gin.H
+	if leave.Status == models.LeaveStatusPending && leave.ApprovalStep >= 1 && leave.ApprovalStep <= len(chain) {
+		waitingFor = gin.H{
+			"step":          leave.ApprovalStep,
+			"approver_role": chain[leave.ApprovalStep-1],
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Detail leave berhasil diambil",
+		"data": gin.H{
+			"id":                    leave.ID,
+			"user_tad_id":           leave.UserTadID,
+			"user_name":             requester.Name,
+			"company_id":            leave.CompanyID,
+			"branch_id":             leave.BranchID,
+			"leave_type_id":         leave.LeaveTypeID,
+			"type_leave":            leave.TypeLeave,
+			"code":                  leave.Code,
+			"user_coordinator_id":   leave.UserCoordinatorID,
+			"user_client_id":        leave.UserClientID,
+			"date_request":          leave.DateRequest,
+			"date_start":            leave.DateStart.Format("2006-01-02"),
+			"date_end":              leave.DateEnd.Format("2006-01-02"),
+			"note":                  leave.Note,
+			"note_approval":         leave.NoteApproval,
+			"status":                models.LeaveStatusLabel[leave.Status],
+			"approval_step":         leave.ApprovalStep,
+			"approval_chain":        chain,
+			"waiting_for":           waitingFor,
+			"documents":             leaveDocumentsResponse(leave),
+			"timeline":              timeline,
+			"days":                  leave.Days,
+			"shifts":                shifts,
+			"special_approval":      leave.SpecialApproval,
+			"can_cancel":            int(leave.UserTadID) == user.ID && canCancelLeave(leave, time.Now()),
+			"can_approve_or_reject": waitingFor != nil && canApproveLeaveStep(user, leave, chain[leave.ApprovalStep-1]),
+		},
+	})
+}
+
+// canViewLeave cek apakah user boleh melihat leave sesuai perannya
+func canViewLeave(user middleware.AuthUser, leave models.Leave) bool {
+	switch {
+	case user.IsAdmin():
+		return true
+	case int(leave.UserTadID) == user.ID,
+		int(leave.UserCoordinatorID) == user.ID,
+		int(leave.UserClientID) == user.ID:
+		return true
+	case user.IsCoordinator() || user.IsClient():
+		return int(leave.BranchID) == user.BranchID
+	}
+	return false
+}
+
+// scopeLeaveQuery membatasi query leave_new (alias l) sesuai yang boleh dilihat user
+func scopeLeaveQuery(query *gorm.DB, user middleware.AuthUser) *gorm.DB {
+	switch {
+	case user.IsAdmin():
+		return query
+	case user.IsCoordinator() || user.IsClient():
+		return query.Where(
+			"(l.branch_id = ? OR l.user_tad_id = ? OR l.user_coordinator_id = ? OR l.user_client_id = ?)",
+			user.BranchID, user.ID, user.ID, user.ID,
+		)
+	}
+	return query.Where("l.user_tad_id = ?", user.ID)
+}
+
+// pendingForApproverQuery hanya leave pending yang step berjalannya menunggu user ini
+func pendingForApproverQuery(query *gorm.DB, user middleware.AuthUser) *gorm.DB {
+	role := currentApproverRoleSQL()
+
+	conditions := []string{}
+	params := []interface{}{}
+
+	if user.IsCoordinator() {
+		conditions = append(conditions, "("+role+" = ? AND (l.user_coordinator_id = ? OR (COALESCE(l.user_coordinator_id, 0) = 0 AND l.branch_id = ?)))")
+		params = append(params, models.LeaveApproverCoordinator, user.ID, user.BranchID)
+	} else {
+		conditions = append(conditions, "("+role+" = ? AND l.user_coordinator_id = ?)")
+		params = append(params, models.LeaveApproverCoordinator, user.ID)
+	}
+
+	if user.IsClient() {
+		conditions = append(conditions, "("+role+" = ? AND (l.user_client_id = ? OR (COALESCE(l.user_client_id, 0) = 0 AND l.branch_id = ?)))")
+		params = append(params, models.LeaveApproverClient, user.ID, user.BranchID)
+	} else {
+		conditions = append(conditions, "("+role+" = ? AND l.user_client_id = ?)")
+		params = append(params, models.LeaveApproverClient, user.ID)
+	}
+
+	if user.IsAdmin() {
+		conditions = append(conditions, "("+role+" = ?)")
+		params = append(params, models.LeaveApproverAdmin)
+	}
+
+	return query.
+		Where("l.status = ?", models.LeaveStatusPending).
+		Where("("+strings.Join(conditions, " OR ")+")", params...)
+}
+
+// currentApproverRoleSQL ekspresi SQL peran approver step berjalan. Butuh join
+// leave_approval_chain (alias lac); jika company belum punya rantai sendiri dipakai rantai default,
+// dan step setelah rantai habis adalah admin untuk leave special approval.
+func currentApproverRoleSQL() string {
+	cases := make([]string, 0, len(models.DefaultLeaveApprovalChain))
+	for i, role := range models.DefaultLeaveApprovalChain {
+		cases = append(cases, "WHEN "+strconv.Itoa(i+1)+" THEN '"+role+"'")
+	}
+
+	return "COALESCE(lac.approver_role, " +
+		"CASE WHEN NOT EXISTS (SELECT 1 FROM leave_approval_chain c WHERE c.company_id = l.company_id) " +
+		"THEN CASE l.approval_step " + strings.Join(cases, " ") + " END END, " +
+		"CASE WHEN l.special_approval THEN '" + models.LeaveApproverAdmin + "' END)"
+}
+
+// leaveStatusCode menerima kode ("1") atau label ("pending") status leave
+func leaveStatusCode(status string) (string, bool) {
+	if _, ok := models.LeaveStatusLabel[status]; ok {
+		return status, true
+	}
+
+	for code, label := range models.LeaveStatusLabel {
+		if strings.EqualFold(label, status) {
+			return code, true
+		}
+	}
+
+	return "", false
+}
@@ -6,9 +6,11 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"api_patroliku_docker/database"
+	"api_patroliku_docker/events"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -46,9 +48,12 @@ func (h *MasterPatroliHandler) GetMasterPatroliByID(c *gin.Context) {
 
 	// ===== struct response =====
 	type MasterPatroliResponse struct {
-		ID         int    `json:"id"`
-		Kode       string `json:"kode"`
-		NamaLokasi string `json:"nama_lokasi"`
+		ID         int      `json:"id"`
+		Kode       string   `json:"kode"`
+		NamaLokasi string   `json:"nama_lokasi"`
+		Latitude   *float64 `json:"latitude"`
+		Longitude  *float64 `json:"longitude"`
+		Radius     *int     `json:"radius"`
 	}
 
 	var data MasterPatroliResponse
@@ -58,7 +63,10 @@ func (h *MasterPatroliHandler) GetMasterPatroliByID(c *gin.Context) {
 		SELECT 
 			id,
 			kode,
-			nama_lokasi
+			nama_lokasi,
+			latitude,
+			longitude,
+			radius
 		FROM master_patroli
 		WHERE id = ?
 		LIMIT 1
@@ -126,6 +134,55 @@ func (h *MasterPatroliHandler) StorePatroliReport(c *gin.Context) {
 		return
 	}
 
+	// ===== validasi koordinat =====
+	if latitude == "" || longitude == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "latitude dan longitude wajib diisi",
+		})
+		return
+	}
+
+	lat, lng, err := parseCoordinate(latitude, longitude)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// ===== verifikasi lokasi terhadap checkpoint =====
+	check, err := h.verifyCheckpointLocation(idPatroli, lat, lng)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "gagal mengambil posisi checkpoint",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if check.NotFound {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Master patroli tidak ditemukan",
+		})
+		return
+	}
+
+	if check.OutsideRadius && isStrictPatroliLocation() {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"status":  "error",
+			"message": "Lokasi di luar radius checkpoint",
+			"data": gin.H{
+				"distance_meters": check.DistanceMeters,
+				"radius":          check.Radius,
+			},
+		})
+		return
+	}
+
 	// ===== upload image =====
 	file, err := c.FormFile("image")
 	if err != nil {
@@ -148,8 +205,8 @@ func (h *MasterPatroliHandler) StorePatroliReport(c *gin.Context) {
 	// ===== insert database =====
 	query := `
 		INSERT INTO patroli_report
-		(user_id, id_patroli, deskripsi, image_url, created_at, updated_at , latitude , longitude)
-		VALUES (?, ?, ?, ?, ?, ? , ? , ?)
+		(user_id, id_patroli, deskripsi, image_url, created_at, updated_at , latitude , longitude, distance_meters, outside_radius)
+		VALUES (?, ?, ?, ?, ?, ? , ? , ?, ?, ?)
 	`
 
 	if err := h.DB.Exec(
@@ -160,8 +217,10 @@ func (h *MasterPatroliHandler) StorePatroliReport(c *gin.Context) {
 		imageURL,
 		time.Now(),
 		time.Now(),
-		latitude,
-		longitude,
+		strconv.FormatFloat(lat, 'f', -1, 64),
+		strconv.FormatFloat(lng, 'f', -1, 64),
+		check.DistanceMeters,
+		check.OutsideRadius,
 	).Error; err != nil {
 
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -172,17 +231,88 @@ func (h *MasterPatroliHandler) StorePatroliReport(c *gin.Context) {
 		return
 	}
 
+	publishUserEvent(h.DB, events.TypePatroliReport, userID, gin.H{
+		"id_patroli":      idPatroli,
+		"image_url":       imageURL,
+		"deskripsi":       deskripsi,
+		"distance_meters": check.DistanceMeters,
+		"outside_radius":  check.OutsideRadius,
+	})
+
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
 		"message": "Patroli report berhasil disimpan",
 		"data": gin.H{
-			"user_id":    userID,
-			"id_patroli": idPatroli,
-			"image_url":  imageURL,
+			"user_id":         userID,
+			"id_patroli":      idPatroli,
+			"image_url":       imageURL,
+			"distance_meters": check.DistanceMeters,
+			"radius":          check.Radius,
+			"outside_radius":  check.OutsideRadius,
 		},
 	})
 }
 
+// checkpointLocationCheck hasil perbandingan posisi report dengan posisi checkpoint
+type checkpointLocationCheck struct {
+	NotFound       bool
+	DistanceMeters *float64
+	Radius         int
+	OutsideRadius  bool
+}
+
+// verifyCheckpointLocation menghitung jarak report ke checkpoint dan menandai jika di luar radius.
+// Checkpoint tanpa posisi tidak dapat diverifikasi sehingga jarak dikembalikan nil.
+func (h *MasterPatroliHandler) verifyCheckpointLocation(idPatroli int, lat, lng float64) (checkpointLocationCheck, error) {
+	var checkpoint struct {
+		ID        int
+		Latitude  *float64
+		Longitude *float64
+		Radius    *int
+	}
+
+	if err := h.DB.Raw(`
+		SELECT id, latitude, longitude, radius
+		FROM master_patroli
+		WHERE id = ?
+		LIMIT 1
+	`, idPatroli).Scan(&checkpoint).Error; err != nil {
+		return checkpointLocationCheck{}, err
+	}
+
+	if checkpoint.ID == 0 {
+		return checkpointLocationCheck{NotFound: true}, nil
+	}
+
+	result := checkpointLocationCheck{Radius: defaultPatroliRadius()}
+	if checkpoint.Radius != nil && *checkpoint.Radius > 0 {
+		result.Radius = *checkpoint.Radius
+	}
+
+	if checkpoint.Latitude == nil || checkpoint.Longitude == nil {
+		return result, nil
+	}
+
+	distance := haversineDistance(*checkpoint.Latitude, *checkpoint.Longitude, lat, lng)
+	result.DistanceMeters = &distance
+	result.OutsideRadius = distance > float64(result.Radius)
+
+	return result, nil
+}
+
+// defaultPatroliRadius radius (meter) untuk checkpoint yang belum punya radius sendiri
+func defaultPatroliRadius() int {
+	if radius, err := strconv.Atoi(os.Getenv("PATROLI_DEFAULT_RADIUS")); err == nil && radius > 0 {
+		return radius
+	}
+	return 50
+}
+
+// isStrictPatroliLocation jika aktif, report di luar radius checkpoint ditolak
+func isStrictPatroliLocation() bool {
+	return strings.EqualFold(os.Getenv("PATROLI_STRICT_LOCATION"), "true")
+}
+
 func (h *MasterPatroliHandler) savePatroliImage(
 	c *gin.Context,
 	fileHeader *multipart.FileHeader,
@@ -263,13 +393,15 @@ func (h *MasterPatroliHandler) ListPatroliReport(c *gin.Context) {
 
 	// ===== struct response =====
 	type PatroliReportResponse struct {
-		ID         int       `json:"id"`
-		Deskripsi  string    `json:"deskripsi"`
-		ImageURL   string    `json:"image_url"`
-		CreatedAt  time.Time `json:"created_at"`
-		NamaLokasi string    `json:"nama_lokasi"`
-		Latitude   string    `json:"latitude"`
-		Longitude  string    `json:"longitude"`
+		ID             int       `json:"id"`
+		Deskripsi      string    `json:"deskripsi"`
+		ImageURL       string    `json:"image_url"`
+		CreatedAt      time.Time `json:"created_at"`
+		NamaLokasi     string    `json:"nama_lokasi"`
+		Latitude       string    `json:"latitude"`
+		Longitude      string    `json:"longitude"`
+		DistanceMeters *float64  `json:"distance_meters"`
+		OutsideRadius  bool      `json:"outside_radius"`
 	}
 
 	var data []PatroliReportResponse
@@ -283,7 +415,9 @@ func (h *MasterPatroliHandler) ListPatroliReport(c *gin.Context) {
 			pr.created_at,
 			mp.nama_lokasi,
 			pr.latitude,
-			pr.longitude
+			pr.longitude,
+			pr.distance_meters,
+			pr.outside_radius
 		FROM patroli_report pr
 		LEFT JOIN master_patroli mp ON pr.id_patroli = mp.id
 		WHERE pr.user_id = ?
@@ -0,0 +1,965 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"api_patroliku_docker/database"
+	"api_patroliku_docker/middleware"
+	"api_patroliku_docker/models"
+	"api_patroliku_docker/schedule"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxRosterRangeDays batas rentang roster / deteksi konflik sekaligus
+const maxRosterRangeDays = 31
+
+// weeklyConflictWeeks jumlah minggu ke depan yang dicek saat menambah jadwal mingguan
+const weeklyConflictWeeks = 4
+
+// Jenis konflik jadwal
+const (
+	ConflictDoubleBooking = "double_booking" // guard punya dua shift yang waktunya bertumpuk
+	ConflictUncovered     = "uncovered"      // shift branch kekurangan guard dari required_guards
+)
+
+type ScheduleHandler struct {
+	DB *gorm.DB
+}
+
+func NewScheduleHandler() *ScheduleHandler {
+	return &ScheduleHandler{
+		DB: database.GetDB(),
+	}
+}
+
+// scheduleConflict satu konflik jadwal di tanggal tertentu
+type scheduleConflict struct {
+	Type           string `json:"type"`
+	Date           string `json:"date"`
+	UserID         uint   `json:"user_id,omitempty"`
+	UserName       string `json:"user_name,omitempty"`
+	ShiftID        int    `json:"shift_id"`
+	ShiftName      string `json:"shift_name"`
+	OtherDate      string `json:"other_date,omitempty"`
+	OtherShiftID   int    `json:"other_shift_id,omitempty"`
+	OtherShiftName string `json:"other_shift_name,omitempty"`
+	Scheduled      int    `json:"scheduled,omitempty"`
+	Required       int    `json:"required,omitempty"`
+}
+
+// rosterGuard guard yang dijadwalkan di satu shift
+type rosterGuard struct {
+	UserID     uint   `json:"user_id"`
+	UserName   string `json:"user_name"`
+	ScheduleID int    `json:"schedule_id"`
+	Dated      bool   `json:"dated"`
+}
+
+// rosterShift satu shift di satu tanggal beserta guard yang mengisinya
+type rosterShift struct {
+	ShiftID        int           `json:"shift_id"`
+	ShiftName      string        `json:"shift_name"`
+	StartTime      string        `json:"start_time"`
+	EndTime        string        `json:"end_time"`
+	Overnight      bool          `json:"overnight"`
+	RequiredGuards int           `json:"required_guards"`
+	Guards         []rosterGuard `json:"guards"`
+	Uncovered      bool          `json:"uncovered"`
+}
+
+type rosterDay struct {
+	Date   string        `json:"date"`
+	Day    int           `json:"day"`
+	Shifts []rosterShift `json:"shifts"`
+}
+
+type branchGuard struct {
+	ID   uint
+	Name string
+}
+
+// ===== jadwal guard =====
+
+// GetSchedules - GET /api/v1/schedules?user_id=12&start_date=2025-12-01&end_date=2025-12-31 (koordinator)
+// Pola mingguan guard beserta jadwal bertanggal di rentang tanggal (default 30 hari ke depan).
+func (h *ScheduleHandler) GetSchedules(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Query("user_id"))
+	if err != nil || userID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "user_id wajib diisi",
+		})
+		return
+	}
+
+	if _, ok := h.guardBranch(c, uint(userID)); !ok {
+		return
+	}
+
+	start, end, ok := rosterRange(c, 30)
+	if !ok {
+		return
+	}
+
+	type ScheduleRow struct {
+		ID        uint       `json:"id"`
+		Day       *int       `json:"day"`
+		Date      *time.Time `json:"date"`
+		Holiday   bool       `json:"holiday"`
+		ShiftID   *uint      `json:"shift_id"`
+		ShiftName *string    `json:"shift_name"`
+		StartTime *string    `json:"start_time"`
+		EndTime   *string    `json:"end_time"`
+	}
+
+	var rows []ScheduleRow
+	if err := h.DB.Raw(`
+		SELECT
+			s.id,
+			s.day,
+			s.date_check_in AS date,
+			COALESCE(s.holiday, FALSE) AS holiday,
+			ss.id AS shift_id,
+			ss.name AS shift_name,
+			ss.start_time,
+			ss.end_time
+		FROM schedule s
+		LEFT JOIN schedule_shift ss ON ss.id = s.schedule_shift_id
+		WHERE s.users_id = ?
+		  AND (s.date_check_in IS NULL OR DATE(s.date_check_in) BETWEEN ? AND ?)
+		ORDER BY s.date_check_in ASC NULLS FIRST, s.day ASC, ss.start_time ASC
+	`, userID, start.Format("2006-01-02"), end.Format("2006-01-02")).Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil jadwal",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	weekly := []ScheduleRow{}
+	dated := []ScheduleRow{}
+	for _, row := range rows {
+		if row.Date == nil {
+			weekly = append(weekly, row)
+		} else {
+			dated = append(dated, row)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Jadwal berhasil diambil",
+		"data": gin.H{
+			"user_id":    userID,
+			"start_date": start.Format("2006-01-02"),
+			"end_date":   end.Format("2006-01-02"),
+			"weekly":     weekly,
+			"dated":      dated,
+		},
+	})
+}
+
+// AssignSchedule - POST /api/v1/schedules (koordinator)
+// Body: user_id, shift_id, days (pola mingguan 1=Senin ... 7=Minggu) dan/atau dates (YYYY-MM-DD).
+// Shift di dates ditambahkan ke jadwal guard di tanggal tersebut, shift pola mingguan lain tetap
+// berlaku. Ditolak dengan 409 jika shift bertumpuk dengan shift lain guard; shift yang sudah ada di
+// jadwal dilewati.
+func (h *ScheduleHandler) AssignSchedule(c *gin.Context) {
+	var req models.ScheduleAssignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Data request tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if len(req.Days) == 0 && len(req.Dates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "days atau dates wajib diisi",
+		})
+		return
+	}
+
+	days := []int{}
+	for _, day := range req.Days {
+		if day < 1 || day > 7 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "days harus bernilai 1 (Senin) sampai 7 (Minggu)",
+			})
+			return
+		}
+		if !containsInt(days, day) {
+			days = append(days, day)
+		}
+	}
+
+	dates := []time.Time{}
+	seen := map[string]bool{}
+	for _, raw := range req.Dates {
+		date, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "format dates harus YYYY-MM-DD",
+			})
+			return
+		}
+		if !seen[raw] {
+			seen[raw] = true
+			dates = append(dates, date)
+		}
+	}
+
+	branchID, ok := h.guardBranch(c, req.UserID)
+	if !ok {
+		return
+	}
+
+	var shift models.ScheduleShift
+	err := h.DB.Where("id = ? AND deleted_at IS NULL", req.ShiftID).
+		Where("branch_id IS NULL OR branch_id = ?", branchID).
+		First(&shift).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Shift tidak ditemukan di branch guard",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil shift",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	conflicts, err := h.bookingConflicts(req.UserID, shift, days, dates)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengecek konflik jadwal",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if len(conflicts) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"message": "Jadwal bertabrakan dengan shift lain guard",
+			"data":    conflicts,
+		})
+		return
+	}
+
+	created := []models.Schedule{}
+	skipped := 0
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		for _, day := range days {
+			var count int64
+			tx.Model(&models.Schedule{}).
+				Where("users_id = ? AND date_check_in IS NULL AND day = ? AND schedule_shift_id = ?", req.UserID, day, shift.ID).
+				Count(&count)
+			if count > 0 {
+				skipped++
+				continue
+			}
+
+			dayNum := day
+			row := models.Schedule{UsersID: req.UserID, Day: &dayNum, ScheduleShiftID: &shift.ID}
+			if err := tx.Create(&row).Error; err != nil {
+				return err
+			}
+			created = append(created, row)
+		}
+
+		for _, date := range dates {
+			day, err := schedule.OnDate(tx, req.UserID, date)
+			if err != nil {
+				return err
+			}
+			if dayHasShift(day, shift.ID) {
+				skipped++
+				continue
+			}
+
+			// shift pola mingguan lain di tanggal ini ikut disalin menjadi jadwal bertanggal
+			if err := schedule.Apply(tx, schedule.Change{UserID: req.UserID, Date: date, Add: shift.ID}); err != nil {
+				return err
+			}
+
+			var row models.Schedule
+			if err := tx.Where("users_id = ? AND DATE(date_check_in) = ? AND schedule_shift_id = ?", req.UserID, date.Format("2006-01-02"), shift.ID).
+				First(&row).Error; err != nil {
+				return err
+			}
+			created = append(created, row)
+		}
+
+		return nil
+	})
+	if errors.Is(err, schedule.ErrDoubleBooking) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"message": "Jadwal bertabrakan dengan shift lain guard",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal menyimpan jadwal",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": fmt.Sprintf("%d jadwal berhasil dibuat", len(created)),
+		"data": gin.H{
+			"created": created,
+			"skipped": skipped,
+		},
+	})
+}
+
+// dayHasShift shift sudah ada di jadwal hari tersebut
+func dayHasShift(day schedule.Day, shiftID uint) bool {
+	for _, shift := range day.Shifts {
+		if uint(shift.ShiftID) == shiftID {
+			return true
+		}
+	}
+	return false
+}
+
+// DeleteSchedule - DELETE /api/v1/schedules/:id (koordinator)
+func (h *ScheduleHandler) DeleteSchedule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "ID jadwal tidak valid",
+		})
+		return
+	}
+
+	var row models.Schedule
+	err = h.DB.Where("id = ?", id).First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Jadwal tidak ditemukan",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil jadwal",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if _, ok := h.guardBranch(c, row.UsersID); !ok {
+		return
+	}
+
+	if err := h.DB.Delete(&models.Schedule{}, row.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal menghapus jadwal",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Jadwal berhasil dihapus",
+	})
+}
+
+// CopyWeek - POST /api/v1/schedules/copy-week (koordinator)
+// Menyalin jadwal bertanggal guard branch dari minggu sumber ke minggu tujuan (Senin - Minggu).
+// Pola mingguan tidak perlu disalin karena sudah berlaku setiap minggu.
+func (h *ScheduleHandler) CopyWeek(c *gin.Context) {
+	user, _ := middleware.CurrentUser(c)
+
+	var req models.ScheduleCopyWeekRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Data request tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	branchID, ok := scheduleBranch(c, user, req.BranchID)
+	if !ok {
+		return
+	}
+
+	sourceDate, errSource := time.Parse("2006-01-02", req.SourceWeek)
+	targetDate, errTarget := time.Parse("2006-01-02", req.TargetWeek)
+	if errSource != nil || errTarget != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "format source_week dan target_week harus YYYY-MM-DD",
+		})
+		return
+	}
+
+	source := weekStart(sourceDate)
+	target := weekStart(targetDate)
+	if source.Equal(target) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Minggu tujuan harus berbeda dengan minggu sumber",
+		})
+		return
+	}
+	offsetDays := int(target.Sub(source).Hours() / 24)
+
+	var rows []models.Schedule
+	if err := h.DB.Table("schedule s").
+		Select("s.*").
+		Joins("JOIN user_tad_information uti ON uti.user_id = s.users_id").
+		Where("uti.branch_id = ?", branchID).
+		Where("DATE(s.date_check_in) BETWEEN ? AND ?", source.Format("2006-01-02"), source.AddDate(0, 0, 6).Format("2006-01-02")).
+		Order("s.date_check_in ASC, s.id ASC").
+		Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil jadwal minggu sumber",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	type copyKey struct {
+		userID uint
+		date   string
+	}
+	type SkippedCopy struct {
+		UserID uint   `json:"user_id"`
+		Date   string `json:"date"`
+	}
+
+	copied := 0
+	skipped := []SkippedCopy{}
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		// keputusan timpa/lewati sekali per guard per tanggal tujuan
+		decided := map[copyKey]bool{}
+		for _, row := range rows {
+			dateCheckIn := row.DateCheckIn.AddDate(0, 0, offsetDays)
+			key := copyKey{row.UsersID, dateCheckIn.Format("2006-01-02")}
+
+			allowed, seen := decided[key]
+			if !seen {
+				var existing int64
+				tx.Model(&models.Schedule{}).
+					Where("users_id = ? AND DATE(date_check_in) = ?", key.userID, key.date).
+					Count(&existing)
+
+				allowed = existing == 0 || req.Overwrite
+				if existing > 0 && req.Overwrite {
+					if err := tx.Where("users_id = ? AND DATE(date_check_in) = ?", key.userID, key.date).
+						Delete(&models.Schedule{}).Error; err != nil {
+						return err
+					}
+				}
+				if !allowed {
+					skipped = append(skipped, SkippedCopy{UserID: key.userID, Date: key.date})
+				}
+				decided[key] = allowed
+			}
+			if !allowed {
+				continue
+			}
+
+			dayNum := schedule.DayNumber(dateCheckIn)
+			entry := models.Schedule{
+				UsersID:         row.UsersID,
+				Day:             &dayNum,
+				DateCheckIn:     &dateCheckIn,
+				Holiday:         row.Holiday,
+				ScheduleShiftID: row.ScheduleShiftID,
+			}
+			if err := tx.Create(&entry).Error; err != nil {
+				return err
+			}
+			copied++
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal menyalin jadwal",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// konflik di minggu tujuan dilaporkan sebagai peringatan, salinan tetap disimpan
+	_, conflicts, err := h.buildRoster(branchID, target, target.AddDate(0, 0, 6))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Jadwal tersalin, tetapi gagal mengecek konflik",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": fmt.Sprintf("%d jadwal berhasil disalin", copied),
+		"data": gin.H{
+			"source_week": source.Format("2006-01-02"),
+			"target_week": target.Format("2006-01-02"),
+			"copied":      copied,
+			"skipped":     skipped,
+			"conflicts":   conflicts,
+		},
+	})
+}
+
+// ===== roster & konflik =====
+
+// GetRoster - GET /api/v1/schedules/roster?branch_id=1&start_date=2025-12-01&end_date=2025-12-07 (koordinator)
+// Jadwal semua guard branch per tanggal per shift, default minggu ini, beserta konfliknya.
+func (h *ScheduleHandler) GetRoster(c *gin.Context) {
+	h.roster(c, true)
+}
+
+// GetScheduleConflicts - GET /api/v1/schedules/conflicts?branch_id=1&start_date=2025-12-01&end_date=2025-12-07 (koordinator)
+// Guard yang double-booking dan shift branch yang kekurangan guard.
+func (h *ScheduleHandler) GetScheduleConflicts(c *gin.Context) {
+	h.roster(c, false)
+}
+
+func (h *ScheduleHandler) roster(c *gin.Context, withDays bool) {
+	user, _ := middleware.CurrentUser(c)
+
+	requested, _ := strconv.Atoi(c.Query("branch_id"))
+	branchID, ok := scheduleBranch(c, user, uint(requested))
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	start, end := weekStart(today), weekStart(today).AddDate(0, 0, 6)
+	if c.Query("start_date") != "" || c.Query("end_date") != "" {
+		if start, end, ok = rosterRange(c, 7); !ok {
+			return
+		}
+	}
+
+	days, conflicts, err := h.buildRoster(branchID, start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil roster",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	summary := gin.H{ConflictDoubleBooking: 0, ConflictUncovered: 0}
+	for _, conflict := range conflicts {
+		summary[conflict.Type] = summary[conflict.Type].(int) + 1
+	}
+
+	data := gin.H{
+		"branch_id":  branchID,
+		"start_date": start.Format("2006-01-02"),
+		"end_date":   end.Format("2006-01-02"),
+		"conflicts":  conflicts,
+		"summary":    summary,
+	}
+	message := "Konflik jadwal berhasil diambil"
+	if withDays {
+		data["days"] = days
+		message = "Roster berhasil diambil"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": message,
+		"data":    data,
+	})
+}
+
+// buildRoster menyusun jadwal guard branch per tanggal dan mendeteksi konflik di [start, end]
+func (h *ScheduleHandler) buildRoster(branchID uint, start, end time.Time) ([]rosterDay, []scheduleConflict, error) {
+	var guards []branchGuard
+	if err := h.DB.Raw(`
+		SELECT u.id, u.name
+		FROM users u
+		JOIN user_tad_information uti ON uti.user_id = u.id
+		WHERE uti.branch_id = ?
+		ORDER BY u.name ASC
+	`, branchID).Scan(&guards).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var branchShifts []models.ScheduleShift
+	if err := h.DB.Where("branch_id = ? AND deleted_at IS NULL", branchID).
+		Order("start_time ASC, id ASC").
+		Find(&branchShifts).Error; err != nil {
+		return nil, nil, err
+	}
+
+	days := []rosterDay{}
+	dayIndex := map[string]int{}
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		day := rosterDay{Date: d.Format("2006-01-02"), Day: schedule.DayNumber(d), Shifts: []rosterShift{}}
+		for _, shift := range branchShifts {
+			day.Shifts = append(day.Shifts, rosterShift{
+				ShiftID:        int(shift.ID),
+				ShiftName:      shift.Name,
+				StartTime:      shift.StartTime,
+				EndTime:        shift.EndTime,
+				Overnight:      shift.Overnight,
+				RequiredGuards: shift.RequiredGuards,
+				Guards:         []rosterGuard{},
+			})
+		}
+		dayIndex[day.Date] = len(days)
+		days = append(days, day)
+	}
+
+	// hari sebelumnya ikut diambil untuk shift malam yang masuk ke rentang
+	branchDays, err := schedule.ResolveBranch(h.DB, branchID, start.AddDate(0, 0, -1), end)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	conflicts := []scheduleConflict{}
+	for _, guard := range guards {
+		shifts := []schedule.Shift{}
+		for _, day := range branchDays[guard.ID] {
+			shifts = append(shifts, day.Shifts...)
+		}
+
+		for _, shift := range shifts {
+			i, ok := dayIndex[shift.Date]
+			if !ok || shift.ShiftID == 0 {
+				continue
+			}
+			entry := rosterGuard{UserID: guard.ID, UserName: guard.Name, ScheduleID: shift.ScheduleID, Dated: shift.Dated}
+
+			placed := false
+			for j := range days[i].Shifts {
+				if days[i].Shifts[j].ShiftID == shift.ShiftID {
+					days[i].Shifts[j].Guards = append(days[i].Shifts[j].Guards, entry)
+					placed = true
+					break
+				}
+			}
+			if !placed {
+				days[i].Shifts = append(days[i].Shifts, rosterShift{
+					ShiftID:   shift.ShiftID,
+					ShiftName: shift.ShiftName,
+					StartTime: shift.StartTime,
+					EndTime:   shift.EndTime,
+					Overnight: shift.Overnight,
+					Guards:    []rosterGuard{entry},
+				})
+			}
+		}
+
+		conflicts = append(conflicts, doubleBookings(guard, shifts, start)...)
+	}
+
+	for i := range days {
+		for j := range days[i].Shifts {
+			shift := &days[i].Shifts[j]
+			if shift.RequiredGuards > 0 && len(shift.Guards) < shift.RequiredGuards {
+				shift.Uncovered = true
+				conflicts = append(conflicts, scheduleConflict{
+					Type:      ConflictUncovered,
+					Date:      days[i].Date,
+					ShiftID:   shift.ShiftID,
+					ShiftName: shift.ShiftName,
+					Scheduled: len(shift.Guards),
+					Required:  shift.RequiredGuards,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(conflicts, func(i, j int) bool {
+		return conflicts[i].Date < conflicts[j].Date
+	})
+
+	return days, conflicts, nil
+}
+
+// doubleBookings pasangan shift guard yang waktunya bertumpuk, dilaporkan mulai tanggal from
+func doubleBookings(guard branchGuard, shifts []schedule.Shift, from time.Time) []scheduleConflict {
+	type span struct {
+		shift      schedule.Shift
+		start, end time.Time
+	}
+
+	spans := []span{}
+	for _, shift := range shifts {
+		date, err := time.Parse("2006-01-02", shift.Date)
+		if err != nil || shift.ShiftID == 0 {
+			continue
+		}
+		if start, end, ok := schedule.Window(date, shift.StartTime, shift.EndTime); ok {
+			spans = append(spans, span{shift, start, end})
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start.Before(spans[j].start) })
+
+	conflicts := []scheduleConflict{}
+	fromDate := from.Format("2006-01-02")
+	for i := range spans {
+		for j := i + 1; j < len(spans) && spans[j].start.Before(spans[i].end); j++ {
+			if spans[j].shift.Date < fromDate {
+				continue
+			}
+			conflicts = append(conflicts, scheduleConflict{
+				Type:           ConflictDoubleBooking,
+				Date:           spans[j].shift.Date,
+				UserID:         guard.ID,
+				UserName:       guard.Name,
+				ShiftID:        spans[j].shift.ShiftID,
+				ShiftName:      spans[j].shift.ShiftName,
+				OtherDate:      spans[i].shift.Date,
+				OtherShiftID:   spans[i].shift.ShiftID,
+				OtherShiftName: spans[i].shift.ShiftName,
+			})
+		}
+	}
+	return conflicts
+}
+
+// bookingConflicts shift guard yang bertumpuk dengan shift baru. Pola mingguan dicek untuk
+// beberapa minggu ke depan; jadwal bertanggal baru menggantikan pola mingguan di tanggalnya.
+func (h *ScheduleHandler) bookingConflicts(userID uint, shift models.ScheduleShift, days []int, dates []time.Time) ([]scheduleConflict, error) {
+	// tanggal yang akan memakai shift baru, true = dari jadwal bertanggal
+	candidates := map[string]bool{}
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	for i := 0; i < weeklyConflictWeeks*7; i++ {
+		d := today.AddDate(0, 0, i)
+		if containsInt(days, schedule.DayNumber(d)) {
+			candidates[d.Format("2006-01-02")] = false
+		}
+	}
+	for _, d := range dates {
+		candidates[d.Format("2006-01-02")] = true
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, 0, len(candidates))
+	for date := range candidates {
+		keys = append(keys, date)
+	}
+	sort.Strings(keys)
+
+	first, _ := time.Parse("2006-01-02", keys[0])
+	last, _ := time.Parse("2006-01-02", keys[len(keys)-1])
+	existing, err := schedule.Shifts(h.DB, userID, first.AddDate(0, 0, -1), last.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	byDate := map[string][]schedule.Shift{}
+	for _, s := range existing {
+		byDate[s.Date] = append(byDate[s.Date], s)
+	}
+
+	conflicts := []scheduleConflict{}
+	for _, date := range keys {
+		dated := candidates[date]
+		d, _ := time.Parse("2006-01-02", date)
+
+		sameDay := byDate[date]
+		if !dated && len(sameDay) > 0 && sameDay[0].Dated {
+			// pola mingguan tidak berlaku di tanggal yang punya jadwal bertanggal
+			continue
+		}
+		if dated {
+			kept := []schedule.Shift{}
+			for _, s := range sameDay {
+				if s.Dated {
+					kept = append(kept, s)
+				}
+			}
+			sameDay = kept
+		}
+
+		start, end, ok := schedule.Window(d, shift.StartTime, shift.EndTime)
+		if !ok {
+			continue
+		}
+
+		others := append([]schedule.Shift{}, byDate[d.AddDate(0, 0, -1).Format("2006-01-02")]...)
+		others = append(others, sameDay...)
+		others = append(others, byDate[d.AddDate(0, 0, 1).Format("2006-01-02")]...)
+
+		for _, other := range others {
+			if other.Date == date && other.ShiftID == int(shift.ID) {
+				continue // sudah terjadwal, dilewati saat simpan
+			}
+			otherDate, _ := time.Parse("2006-01-02", other.Date)
+			otherStart, otherEnd, ok := schedule.Window(otherDate, other.StartTime, other.EndTime)
+			if !ok || !start.Before(otherEnd) || !otherStart.Before(end) {
+				continue
+			}
+
+			conflicts = append(conflicts, scheduleConflict{
+				Type:           ConflictDoubleBooking,
+				Date:           date,
+				UserID:         userID,
+				ShiftID:        int(shift.ID),
+				ShiftName:      shift.Name,
+				OtherDate:      other.Date,
+				OtherShiftID:   other.ShiftID,
+				OtherShiftName: other.ShiftName,
+			})
+		}
+	}
+
+	return conflicts, nil
+}
+
+// ===== helper =====
+
+// guardBranch branch guard dari user_tad_information, mengirim error jika guard tidak ditemukan
+// atau di luar branch user yang login
+func (h *ScheduleHandler) guardBranch(c *gin.Context, userID uint) (uint, bool) {
+	user, _ := middleware.CurrentUser(c)
+
+	var branchIDs []uint
+	if err := h.DB.Raw(`SELECT branch_id FROM user_tad_information WHERE user_id = ? LIMIT 1`, userID).
+		Scan(&branchIDs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil data guard",
+			"error":   err.Error(),
+		})
+		return 0, false
+	}
+	if len(branchIDs) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Guard tidak ditemukan",
+		})
+		return 0, false
+	}
+
+	if !user.CanAccessBranch(int(branchIDs[0])) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"message": "Tidak memiliki akses ke jadwal guard ini",
+		})
+		return 0, false
+	}
+
+	return branchIDs[0], true
+}
+
+// scheduleBranch branch yang dikelola: koordinator selalu branch sendiri, admin wajib memilih
+func scheduleBranch(c *gin.Context, user middleware.AuthUser, requested uint) (uint, bool) {
+	if !user.IsAdmin() {
+		if requested != 0 && int(requested) != user.BranchID {
+			c.JSON(http.StatusForbidden, gin.H{
+				"status":  "error",
+				"message": "Tidak memiliki akses ke branch ini",
+			})
+			return 0, false
+		}
+		return uint(user.BranchID), true
+	}
+
+	if requested == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "branch_id wajib diisi",
+		})
+		return 0, false
+	}
+	return requested, true
+}
+
+// rosterRange rentang dari start_date & end_date, default hari ini sampai defaultDays hari ke depan
+func rosterRange(c *gin.Context, defaultDays int) (time.Time, time.Time, bool) {
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, defaultDays-1)
+
+	if raw := c.Query("start_date"); raw != "" {
+		t, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "format start_date harus YYYY-MM-DD",
+			})
+			return start, end, false
+		}
+		start = t
+		end = start.AddDate(0, 0, defaultDays-1)
+	}
+
+	if raw := c.Query("end_date"); raw != "" {
+		t, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "format end_date harus YYYY-MM-DD",
+			})
+			return start, end, false
+		}
+		end = t
+	}
+
+	if end.Before(start) || int(end.Sub(start).Hours()/24)+1 > maxRosterRangeDays {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": fmt.Sprintf("Rentang tanggal tidak valid (maksimal %d hari)", maxRosterRangeDays),
+		})
+		return start, end, false
+	}
+
+	return start, end, true
+}
+
+// weekStart hari Senin di minggu tanggal date
+func weekStart(date time.Time) time.Time {
+	return date.AddDate(0, 0, 1-schedule.DayNumber(date))
+}
+
+func containsInt(list []int, value int) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"api_patroliku_docker/middleware"
+	"api_patroliku_docker/models"
+	"api_patroliku_docker/schedule"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetShifts - GET /api/v1/schedules/shifts?branch_id=1 (koordinator)
+// Shift branch beserta shift umum (tanpa branch).
+func (h *ScheduleHandler) GetShifts(c *gin.Context) {
+	user, _ := middleware.CurrentUser(c)
+
+	query := h.DB.Where("deleted_at IS NULL")
+	if !user.IsAdmin() {
+		query = query.Where("branch_id IS NULL OR branch_id = ?", user.BranchID)
+	} else if branchID := c.Query("branch_id"); branchID != "" {
+		query = query.Where("branch_id IS NULL OR branch_id = ?", branchID)
+	}
+
+	var shifts []models.ScheduleShift
+	if err := query.Order("start_time ASC, id ASC").Find(&shifts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil shift",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Shift berhasil diambil",
+		"data":    shifts,
+	})
+}
+
+// CreateShift - POST /api/v1/schedules/shifts (koordinator)
+func (h *ScheduleHandler) CreateShift(c *gin.Context) {
+	user, _ := middleware.CurrentUser(c)
+
+	var req models.ScheduleShiftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Data request tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	var shift models.ScheduleShift
+	if !applyShiftRequest(c, &shift, req) {
+		return
+	}
+
+	// koordinator hanya membuat shift branch sendiri, admin boleh shift umum
+	if user.IsAdmin() {
+		shift.BranchID = req.BranchID
+	} else {
+		branchID := uint(user.BranchID)
+		shift.BranchID = &branchID
+	}
+
+	if err := h.DB.Omit("deleted_at").Create(&shift).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal menyimpan shift",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Shift berhasil dibuat",
+		"data":    shift,
+	})
+}
+
+// UpdateShift - PUT /api/v1/schedules/shifts/:id (koordinator)
+// Perubahan jam berlaku untuk semua jadwal yang memakai shift ini.
+func (h *ScheduleHandler) UpdateShift(c *gin.Context) {
+	shift, ok := h.loadShift(c)
+	if !ok {
+		return
+	}
+
+	var req models.ScheduleShiftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Data request tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if !applyShiftRequest(c, &shift, req) {
+		return
+	}
+
+	if err := h.DB.Model(&shift).
+		Select("name", "start_time", "end_time", "break_minutes", "overnight", "required_guards").
+		Updates(&shift).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengubah shift",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Shift berhasil diubah",
+		"data":    shift,
+	})
+}
+
+// DeleteShift - DELETE /api/v1/schedules/shifts/:id (koordinator)
+// Ditolak selama masih ada jadwal mingguan atau jadwal bertanggal mendatang yang memakai shift ini.
+func (h *ScheduleHandler) DeleteShift(c *gin.Context) {
+	shift, ok := h.loadShift(c)
+	if !ok {
+		return
+	}
+
+	var used int64
+	h.DB.Model(&models.Schedule{}).
+		Where("schedule_shift_id = ?", shift.ID).
+		Where("date_check_in IS NULL OR DATE(date_check_in) >= ?", time.Now().Format("2006-01-02")).
+		Count(&used)
+	if used > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"message": fmt.Sprintf("Shift masih dipakai di %d jadwal", used),
+		})
+		return
+	}
+
+	if err := h.DB.Model(&models.ScheduleShift{}).
+		Where("id = ?", shift.ID).
+		Update("deleted_at", time.Now()).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal menghapus shift",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Shift berhasil dihapus",
+	})
+}
+
+// applyShiftRequest validasi jam shift dan menyalin request ke shift, mengirim 400 jika tidak valid
+func applyShiftRequest(c *gin.Context, shift *models.ScheduleShift, req models.ScheduleShiftRequest) bool {
+	start, okStart := schedule.ParseClock(req.StartTime)
+	end, okEnd := schedule.ParseClock(req.EndTime)
+	if !okStart || !okEnd {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "format start_time dan end_time harus HH:MM",
+		})
+		return false
+	}
+
+	// shift malam berarti jam selesai jatuh di hari berikutnya
+	overnight := !end.After(start)
+	if req.Overnight != nil && *req.Overnight != overnight {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "overnight hanya untuk shift dengan end_time lebih awal dari start_time",
+		})
+		return false
+	}
+
+	duration := end.Sub(start)
+	if overnight {
+		duration += 24 * time.Hour
+	}
+	if time.Duration(req.BreakMinutes)*time.Minute >= duration {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "break_minutes harus lebih pendek dari durasi shift",
+		})
+		return false
+	}
+
+	shift.Name = req.Name
+	shift.StartTime = start.Format("15:04:05")
+	shift.EndTime = end.Format("15:04:05")
+	shift.BreakMinutes = req.BreakMinutes
+	shift.Overnight = overnight
+	shift.RequiredGuards = req.RequiredGuards
+	return true
+}
+
+// loadShift mengambil shift dari path :id; shift umum hanya boleh dikelola admin
+func (h *ScheduleHandler) loadShift(c *gin.Context) (models.ScheduleShift, bool) {
+	user, _ := middleware.CurrentUser(c)
+	var shift models.ScheduleShift
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "ID shift tidak valid",
+		})
+		return shift, false
+	}
+
+	err = h.DB.Where("id = ? AND deleted_at IS NULL", id).First(&shift).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Shift tidak ditemukan",
+		})
+		return shift, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil shift",
+			"error":   err.Error(),
+		})
+		return shift, false
+	}
+
+	if (shift.BranchID == nil && !user.IsAdmin()) ||
+		(shift.BranchID != nil && !user.CanAccessBranch(int(*shift.BranchID))) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"message": "Tidak memiliki akses ke shift ini",
+		})
+		return shift, false
+	}
+
+	return shift, true
+}
@@ -0,0 +1,623 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"api_patroliku_docker/middleware"
+	"api_patroliku_docker/models"
+	"api_patroliku_docker/notification"
+	"api_patroliku_docker/schedule"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var (
+	errSwapStatusChanged = errors.New("status permintaan tukar shift sudah berubah")
+	errSwapAttended      = errors.New("guard sudah absen di tanggal shift yang ditukar")
+	errSwapPast          = errors.New("tanggal shift sudah lewat")
+)
+
+// shiftSwapView permintaan tukar shift beserta nama guard dan shift
+type shiftSwapView struct {
+	models.ShiftSwapRequest
+	RequesterName        *string `json:"requester_name"`
+	RequesterShiftName   *string `json:"requester_shift_name"`
+	CounterpartName      *string `json:"counterpart_name"`
+	CounterpartShiftName *string `json:"counterpart_shift_name"`
+}
+
+const shiftSwapViewQuery = `
+	SELECT
+		sr.*,
+		ru.name AS requester_name,
+		rs.name AS requester_shift_name,
+		cu.name AS counterpart_name,
+		cs.name AS counterpart_shift_name
+	FROM shift_swap_request sr
+	LEFT JOIN users ru ON ru.id = sr.requester_id
+	LEFT JOIN schedule_shift rs ON rs.id = sr.requester_shift_id
+	LEFT JOIN users cu ON cu.id = sr.counterpart_id
+	LEFT JOIN schedule_shift cs ON cs.id = sr.counterpart_shift_id
+`
+
+// GetShiftSwaps - GET /api/v1/shift-swaps?status=accepted
+// Guard melihat permintaan miliknya (sebagai requester atau rekan), koordinator semua di branch.
+func (h *ScheduleHandler) GetShiftSwaps(c *gin.Context) {
+	user, _ := middleware.CurrentUser(c)
+
+	where := []string{}
+	args := []interface{}{}
+	switch {
+	case user.IsAdmin():
+		if branchID := c.Query("branch_id"); branchID != "" {
+			where = append(where, "sr.branch_id = ?")
+			args = append(args, branchID)
+		}
+	case user.IsSupervisor():
+		where = append(where, "sr.branch_id = ?")
+		args = append(args, user.BranchID)
+	default:
+		where = append(where, "(sr.requester_id = ? OR sr.counterpart_id = ?)")
+		args = append(args, user.ID, user.ID)
+	}
+
+	if status := c.Query("status"); status != "" {
+		where = append(where, "sr.status IN ?")
+		args = append(args, strings.Split(status, ","))
+	}
+
+	query := shiftSwapViewQuery
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY sr.created_at DESC LIMIT 100"
+
+	swaps := []shiftSwapView{}
+	if err := h.DB.Raw(query, args...).Scan(&swaps).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil permintaan tukar shift",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Permintaan tukar shift berhasil diambil",
+		"data":    swaps,
+	})
+}
+
+// GetShiftSwapDetail - GET /api/v1/shift-swaps/:id
+func (h *ScheduleHandler) GetShiftSwapDetail(c *gin.Context) {
+	swap, _, ok := h.loadShiftSwap(c)
+	if !ok {
+		return
+	}
+
+	var view shiftSwapView
+	if err := h.DB.Raw(shiftSwapViewQuery+" WHERE sr.id = ?", swap.ID).Scan(&view).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil permintaan tukar shift",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Permintaan tukar shift berhasil diambil",
+		"data":    view,
+	})
+}
+
+// CreateShiftSwap - POST /api/v1/shift-swaps
+// Guard mengajukan tukar shift (swap) atau meminta rekan satu branch menggantikan shiftnya (cover)
+// di tanggal tertentu. Rekan harus menerima, lalu koordinator menyetujui.
+func (h *ScheduleHandler) CreateShiftSwap(c *gin.Context) {
+	user, _ := middleware.CurrentUser(c)
+
+	var req models.ShiftSwapCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Data request tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if req.CounterpartID == uint(user.ID) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Tidak dapat menukar shift dengan diri sendiri",
+		})
+		return
+	}
+
+	requesterDate, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "format date harus YYYY-MM-DD",
+		})
+		return
+	}
+
+	swap := models.ShiftSwapRequest{
+		Type:             req.Type,
+		Status:           models.ShiftSwapStatusPending,
+		Reason:           strings.TrimSpace(req.Reason),
+		RequesterID:      uint(user.ID),
+		RequesterDate:    requesterDate,
+		RequesterShiftID: req.ShiftID,
+		CounterpartID:    req.CounterpartID,
+	}
+
+	if req.Type == models.ShiftSwapTypeSwap {
+		if req.CounterpartShiftID == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "counterpart_shift_id wajib diisi untuk tukar shift",
+			})
+			return
+		}
+
+		counterpartDate := requesterDate
+		if req.CounterpartDate != "" {
+			if counterpartDate, err = time.Parse("2006-01-02", req.CounterpartDate); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"status":  "error",
+					"message": "format counterpart_date harus YYYY-MM-DD",
+				})
+				return
+			}
+		}
+		swap.CounterpartDate = &counterpartDate
+		swap.CounterpartShiftID = &req.CounterpartShiftID
+	}
+
+	if swapInPast(swap) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Tanggal shift tidak boleh sebelum hari ini",
+		})
+		return
+	}
+
+	// ===== requester & rekan harus satu branch =====
+	requesterBranch, err := lookupUserBranchID(h.DB, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil branch guard",
+			"error":   err.Error(),
+		})
+		return
+	}
+	counterpartBranch, err := lookupUserBranchID(h.DB, int(req.CounterpartID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil branch rekan",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if requesterBranch == 0 || counterpartBranch != requesterBranch {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Rekan harus dari branch yang sama",
+		})
+		return
+	}
+	swap.BranchID = uint(requesterBranch)
+
+	var active int64
+	h.DB.Model(&models.ShiftSwapRequest{}).
+		Where("requester_id = ? AND requester_date = ? AND requester_shift_id = ? AND status IN ?",
+			swap.RequesterID, req.Date, swap.RequesterShiftID,
+			[]string{models.ShiftSwapStatusPending, models.ShiftSwapStatusAccepted}).
+		Count(&active)
+	if active > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"message": "Shift ini sudah punya permintaan tukar yang masih berjalan",
+		})
+		return
+	}
+
+	// roster belum diubah, hanya dicek supaya permintaan yang mustahil langsung ditolak
+	for _, change := range swapChanges(swap) {
+		if _, err := schedule.Plan(h.DB, change); err != nil {
+			respondSwapError(c, err)
+			return
+		}
+	}
+
+	if err := h.DB.Create(&swap).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal menyimpan permintaan tukar shift",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	title := "Permintaan gantikan shift"
+	if swap.Type == models.ShiftSwapTypeSwap {
+		title = "Permintaan tukar shift"
+	}
+	h.notifySwap([]int{int(swap.CounterpartID)}, "shift_swap_requested", title,
+		"Rekan Anda meminta persetujuan untuk shift tanggal "+req.Date, swap)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Permintaan tukar shift berhasil diajukan",
+		"data":    swap,
+	})
+}
+
+// AcceptShiftSwap - POST /api/v1/shift-swaps/:id/accept (rekan)
+func (h *ScheduleHandler) AcceptShiftSwap(c *gin.Context) {
+	h.respondShiftSwap(c, models.ShiftSwapStatusAccepted)
+}
+
+// DeclineShiftSwap - POST /api/v1/shift-swaps/:id/decline (rekan)
+func (h *ScheduleHandler) DeclineShiftSwap(c *gin.Context) {
+	h.respondShiftSwap(c, models.ShiftSwapStatusDeclined)
+}
+
+func (h *ScheduleHandler) respondShiftSwap(c *gin.Context, to string) {
+	swap, user, ok := h.loadShiftSwap(c)
+	if !ok {
+		return
+	}
+
+	if swap.CounterpartID != uint(user.ID) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"message": "Hanya rekan yang diminta yang dapat menjawab",
+		})
+		return
+	}
+
+	var req models.ShiftSwapResponseRequest
+	_ = c.ShouldBindJSON(&req)
+
+	now := time.Now()
+	err := updateSwapStatus(h.DB, swap.ID, []string{models.ShiftSwapStatusPending}, map[string]interface{}{
+		"status":           to,
+		"counterpart_note": strings.TrimSpace(req.Note),
+		"responded_at":     now,
+	})
+	if err != nil {
+		respondSwapError(c, err)
+		return
+	}
+
+	if to == models.ShiftSwapStatusAccepted {
+		h.notifySwap([]int{int(swap.RequesterID)}, "shift_swap_accepted",
+			"Permintaan tukar shift diterima", "Rekan menerima permintaan Anda, menunggu persetujuan koordinator", swap)
+
+		coordinators, err := notification.BranchUsersByType(h.DB, int(swap.BranchID), middleware.CoordinatorUserTypes)
+		if err != nil {
+			log.Printf("⚠️ Gagal mengambil koordinator branch %d: %v", swap.BranchID, err)
+		}
+		h.notifySwap(coordinators, "shift_swap_pending_approval",
+			"Tukar shift menunggu persetujuan", "Ada permintaan tukar shift yang perlu disetujui", swap)
+	} else {
+		h.notifySwap([]int{int(swap.RequesterID)}, "shift_swap_declined",
+			"Permintaan tukar shift ditolak", "Rekan menolak permintaan tukar shift Anda", swap)
+	}
+
+	message := "Permintaan tukar shift diterima, menunggu persetujuan koordinator"
+	if to == models.ShiftSwapStatusDeclined {
+		message = "Permintaan tukar shift ditolak"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": message,
+		"data": gin.H{
+			"id":           swap.ID,
+			"status":       to,
+			"responded_at": now,
+		},
+	})
+}
+
+// CancelShiftSwap - POST /api/v1/shift-swaps/:id/cancel (requester)
+func (h *ScheduleHandler) CancelShiftSwap(c *gin.Context) {
+	swap, user, ok := h.loadShiftSwap(c)
+	if !ok {
+		return
+	}
+
+	if swap.RequesterID != uint(user.ID) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"message": "Hanya pengaju yang dapat membatalkan",
+		})
+		return
+	}
+
+	err := updateSwapStatus(h.DB, swap.ID,
+		[]string{models.ShiftSwapStatusPending, models.ShiftSwapStatusAccepted},
+		map[string]interface{}{"status": models.ShiftSwapStatusCancelled})
+	if err != nil {
+		respondSwapError(c, err)
+		return
+	}
+
+	h.notifySwap([]int{int(swap.CounterpartID)}, "shift_swap_cancelled",
+		"Permintaan tukar shift dibatalkan", "Rekan membatalkan permintaan tukar shift", swap)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Permintaan tukar shift dibatalkan",
+		"data": gin.H{
+			"id":     swap.ID,
+			"status": models.ShiftSwapStatusCancelled,
+		},
+	})
+}
+
+// ApproveShiftSwap - POST /api/v1/shift-swaps/:id/approve (koordinator)
+// Roster diubah lewat jadwal bertanggal, sehingga absensi dan keterlambatan dihitung
+// terhadap guard yang benar-benar menjalani shift.
+func (h *ScheduleHandler) ApproveShiftSwap(c *gin.Context) {
+	h.reviewShiftSwap(c, models.ShiftSwapStatusApproved)
+}
+
+// RejectShiftSwap - POST /api/v1/shift-swaps/:id/reject (koordinator)
+func (h *ScheduleHandler) RejectShiftSwap(c *gin.Context) {
+	h.reviewShiftSwap(c, models.ShiftSwapStatusRejected)
+}
+
+func (h *ScheduleHandler) reviewShiftSwap(c *gin.Context, to string) {
+	swap, user, ok := h.loadShiftSwap(c)
+	if !ok {
+		return
+	}
+
+	if !user.IsSupervisor() || !user.CanAccessBranch(int(swap.BranchID)) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"message": "Hanya koordinator branch yang dapat memutuskan tukar shift",
+		})
+		return
+	}
+
+	var req models.ShiftSwapResponseRequest
+	_ = c.ShouldBindJSON(&req)
+	req.Note = strings.TrimSpace(req.Note)
+
+	if to == models.ShiftSwapStatusRejected && req.Note == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Alasan penolakan wajib diisi",
+		})
+		return
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":      to,
+		"reviewed_by": user.ID,
+		"reviewed_at": now,
+		"review_note": req.Note,
+	}
+
+	var err error
+	if to == models.ShiftSwapStatusApproved {
+		err = h.DB.Transaction(func(tx *gorm.DB) error {
+			if err := updateSwapStatus(tx, swap.ID, []string{models.ShiftSwapStatusAccepted}, updates); err != nil {
+				return err
+			}
+			return applyShiftSwap(tx, swap)
+		})
+	} else {
+		err = updateSwapStatus(h.DB, swap.ID,
+			[]string{models.ShiftSwapStatusPending, models.ShiftSwapStatusAccepted}, updates)
+	}
+	if err != nil {
+		respondSwapError(c, err)
+		return
+	}
+
+	recipients := []int{int(swap.RequesterID), int(swap.CounterpartID)}
+	if to == models.ShiftSwapStatusApproved {
+		h.notifySwap(recipients, "shift_swap_approved",
+			"Tukar shift disetujui", "Koordinator menyetujui tukar shift, jadwal sudah diperbarui", swap)
+	} else {
+		h.notifySwap(recipients, "shift_swap_rejected",
+			"Tukar shift ditolak", "Koordinator menolak tukar shift: "+req.Note, swap)
+	}
+
+	message := "Tukar shift disetujui, jadwal sudah diperbarui"
+	if to == models.ShiftSwapStatusRejected {
+		message = "Tukar shift ditolak"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": message,
+		"data": gin.H{
+			"id":          swap.ID,
+			"status":      to,
+			"reviewed_by": user.ID,
+			"reviewed_at": now,
+			"review_note": req.Note,
+		},
+	})
+}
+
+// applyShiftSwap mengubah roster sesuai permintaan yang disetujui
+func applyShiftSwap(tx *gorm.DB, swap models.ShiftSwapRequest) error {
+	if swapInPast(swap) {
+		return errSwapPast
+	}
+
+	changes := swapChanges(swap)
+	for _, change := range changes {
+		if change.Remove == 0 {
+			continue
+		}
+
+		// shift yang sudah diabsen tidak bisa dipindahkan ke guard lain
+		var attended int64
+		tx.Model(&models.UserAttendance{}).
+			Where("users_id = ? AND date_attendence = ? AND check_in IS NOT NULL",
+				change.UserID, change.Date.Format("2006-01-02")).
+			Count(&attended)
+		if attended > 0 {
+			return errSwapAttended
+		}
+	}
+
+	return schedule.Apply(tx, changes...)
+}
+
+// swapChanges perubahan jadwal per guard per tanggal.
+// cover: requester melepas shiftnya, rekan mengambilnya.
+// swap: kedua guard saling melepas dan mengambil shift masing-masing.
+func swapChanges(swap models.ShiftSwapRequest) []schedule.Change {
+	changes := []schedule.Change{
+		{UserID: swap.RequesterID, Date: swap.RequesterDate, Remove: swap.RequesterShiftID},
+		{UserID: swap.CounterpartID, Date: swap.RequesterDate, Add: swap.RequesterShiftID},
+	}
+
+	if swap.Type != models.ShiftSwapTypeSwap || swap.CounterpartDate == nil || swap.CounterpartShiftID == nil {
+		return changes
+	}
+
+	// tanggal yang sama digabung supaya shift yang dilepas tidak dianggap bertumpuk
+	if swap.CounterpartDate.Format("2006-01-02") == swap.RequesterDate.Format("2006-01-02") {
+		changes[0].Add = *swap.CounterpartShiftID
+		changes[1].Remove = *swap.CounterpartShiftID
+		return changes
+	}
+
+	return append(changes,
+		schedule.Change{UserID: swap.CounterpartID, Date: *swap.CounterpartDate, Remove: *swap.CounterpartShiftID},
+		schedule.Change{UserID: swap.RequesterID, Date: *swap.CounterpartDate, Add: *swap.CounterpartShiftID},
+	)
+}
+
+// swapInPast salah satu tanggal shift sudah lewat
+func swapInPast(swap models.ShiftSwapRequest) bool {
+	today := time.Now().Format("2006-01-02")
+	if swap.RequesterDate.Format("2006-01-02") < today {
+		return true
+	}
+	return swap.CounterpartDate != nil && swap.CounterpartDate.Format("2006-01-02") < today
+}
+
+// updateSwapStatus mengubah status hanya jika status saat ini masih salah satu dari from
+func updateSwapStatus(db *gorm.DB, id uint, from []string, updates map[string]interface{}) error {
+	result := db.Model(&models.ShiftSwapRequest{}).
+		Where("id = ? AND status IN ?", id, from).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errSwapStatusChanged
+	}
+	return nil
+}
+
+func respondSwapError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errSwapStatusChanged):
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"message": "Status permintaan tukar shift sudah berubah, muat ulang data",
+		})
+	case errors.Is(err, schedule.ErrShiftNotScheduled):
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"message": "Shift yang ditukar tidak ada lagi di jadwal guard",
+		})
+	case errors.Is(err, schedule.ErrDoubleBooking):
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"message": "Tukar shift membuat jadwal guard bertumpuk",
+		})
+	case errors.Is(err, schedule.ErrShiftNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Shift tidak ditemukan",
+		})
+	case errors.Is(err, errSwapAttended), errors.Is(err, errSwapPast):
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal memproses permintaan tukar shift",
+			"error":   err.Error(),
+		})
+	}
+}
+
+// loadShiftSwap mengambil permintaan dari path :id; hanya requester, rekan dan koordinator branch
+func (h *ScheduleHandler) loadShiftSwap(c *gin.Context) (models.ShiftSwapRequest, middleware.AuthUser, bool) {
+	user, _ := middleware.CurrentUser(c)
+	var swap models.ShiftSwapRequest
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "ID permintaan tukar shift tidak valid",
+		})
+		return swap, user, false
+	}
+
+	err = h.DB.Where("id = ?", id).First(&swap).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Permintaan tukar shift tidak ditemukan",
+		})
+		return swap, user, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil permintaan tukar shift",
+			"error":   err.Error(),
+		})
+		return swap, user, false
+	}
+
+	involved := swap.RequesterID == uint(user.ID) || swap.CounterpartID == uint(user.ID)
+	if !involved && !(user.IsSupervisor() && user.CanAccessBranch(int(swap.BranchID))) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"message": "Tidak memiliki akses ke permintaan ini",
+		})
+		return swap, user, false
+	}
+
+	return swap, user, true
+}
+
+func (h *ScheduleHandler) notifySwap(recipients []int, notifType, title, body string, swap models.ShiftSwapRequest) {
+	if err := notification.Send(h.DB, recipients, notifType, title, body,
+		models.JSONMap{"shift_swap_id": swap.ID, "type": swap.Type}); err != nil {
+		log.Printf("⚠️ Gagal mengirim notifikasi tukar shift %d: %v", swap.ID, err)
+	}
+}
@@ -0,0 +1,579 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"api_patroliku_docker/database"
+	"api_patroliku_docker/events"
+	"api_patroliku_docker/middleware"
+	"api_patroliku_docker/models"
+	"api_patroliku_docker/notification"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// sosEscalationLevel satu tingkat di rantai eskalasi SOS
+type sosEscalationLevel struct {
+	Name       string
+	UserTypes  []string
+	BranchOnly bool
+}
+
+// sosEscalationChain urutan penerima SOS. Level 1 dikirim saat SOS dibuat,
+// level berikutnya dikirim jika belum ada yang acknowledge dalam SOS_ESCALATION_MINUTES.
+var sosEscalationChain = []sosEscalationLevel{
+	{
+		Name:       "Koordinator & client branch",
+		UserTypes:  append(append([]string{}, middleware.CoordinatorUserTypes...), middleware.ClientUserTypes...),
+		BranchOnly: true,
+	},
+	{
+		Name:       "Admin pusat",
+		UserTypes:  middleware.AdminUserTypes,
+		BranchOnly: false,
+	},
+}
+
+var errSOSStatusChanged = errors.New("status SOS sudah diubah oleh user lain")
+
+type SOSHandler struct {
+	DB *gorm.DB
+}
+
+func NewSOSHandler() *SOSHandler {
+	return &SOSHandler{
+		DB: database.GetDB(),
+	}
+}
+
+// sosEscalationInterval jeda sebelum SOS dieskalasi ke level berikutnya
+func sosEscalationInterval() time.Duration {
+	if minutes, err := strconv.Atoi(os.Getenv("SOS_ESCALATION_MINUTES")); err == nil && minutes > 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+	return 5 * time.Minute
+}
+
+// CreateSOS - POST /api/v1/sos
+func (h *SOSHandler) CreateSOS(c *gin.Context) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  "error",
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	var req struct {
+		Latitude  *float64 `json:"latitude" binding:"required"`
+		Longitude *float64 `json:"longitude" binding:"required"`
+		Note      string   `json:"note"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "latitude dan longitude wajib diisi",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := validateCoordinate(*req.Latitude, *req.Longitude); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// ===== tombol ditekan berulang: kembalikan SOS yang masih aktif =====
+	var active models.SOSEvent
+	err := h.DB.Where("user_id = ? AND status IN ?", user.ID,
+		[]string{models.SOSStatusOpen, models.SOSStatusAcknowledged}).
+		Order("id DESC").
+		First(&active).Error
+
+	if err == nil {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "success",
+			"message": "SOS masih aktif",
+			"data":    active,
+		})
+		return
+	}
+
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal memeriksa SOS aktif",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	actorID := uint(user.ID)
+	event := models.SOSEvent{
+		UserID:          uint(user.ID),
+		BranchID:        uint(user.BranchID),
+		Latitude:        *req.Latitude,
+		Longitude:       *req.Longitude,
+		Note:            req.Note,
+		Status:          models.SOSStatusOpen,
+		EscalationLevel: 1,
+		LastEscalatedAt: time.Now(),
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&event).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&models.SOSEventLog{
+			SOSEventID:  event.ID,
+			Action:      models.SOSActionCreated,
+			Level:       0,
+			ActorUserID: &actorID,
+			Note:        req.Note,
+			Data: models.JSONMap{
+				"latitude":  event.Latitude,
+				"longitude": event.Longitude,
+			},
+		}).Error
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal menyimpan SOS",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	events.Publish(events.Event{
+		Type:     events.TypeSOSCreated,
+		BranchID: int(event.BranchID),
+		UserID:   int(event.UserID),
+		Data:     event,
+	})
+
+	h.notifyLevel(event, 1, models.SOSActionNotified)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "SOS berhasil dikirim",
+		"data":    event,
+	})
+}
+
+// ListSOS - GET /api/v1/sos
+func (h *SOSHandler) ListSOS(c *gin.Context) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  "error",
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	query := h.DB.Model(&models.SOSEvent{})
+
+	switch {
+	case user.IsAdmin():
+		if branchID := c.Query("branch_id"); branchID != "" {
+			query = query.Where("branch_id = ?", branchID)
+		}
+	case user.IsSupervisor() || user.IsClient():
+		query = query.Where("branch_id = ?", user.BranchID)
+	default:
+		query = query.Where("user_id = ?", user.ID)
+	}
+
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var sosEvents []models.SOSEvent
+	if err := query.Order("created_at DESC").Limit(100).Find(&sosEvents).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil data SOS",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Data SOS berhasil diambil",
+		"data":    sosEvents,
+	})
+}
+
+// GetSOSDetail - GET /api/v1/sos/:id beserta log lengkap untuk review
+func (h *SOSHandler) GetSOSDetail(c *gin.Context) {
+	event, _, ok := h.loadSOS(c)
+	if !ok {
+		return
+	}
+
+	var logs []models.SOSEventLog
+	if err := h.DB.Where("sos_event_id = ?", event.ID).
+		Order("created_at ASC, id ASC").
+		Find(&logs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil log SOS",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Detail SOS berhasil diambil",
+		"data": gin.H{
+			"event": event,
+			"logs":  logs,
+		},
+	})
+}
+
+// AcknowledgeSOS - POST /api/v1/sos/:id/acknowledge
+func (h *SOSHandler) AcknowledgeSOS(c *gin.Context) {
+	event, user, ok := h.loadSOS(c)
+	if !ok {
+		return
+	}
+
+	if !user.IsSupervisor() && !user.IsClient() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"message": "Hanya koordinator atau client yang dapat acknowledge SOS",
+		})
+		return
+	}
+
+	var req struct {
+		Note string `json:"note"`
+	}
+	_ = c.ShouldBindJSON(&req)
+
+	now := time.Now()
+	actorID := uint(user.ID)
+
+	err := h.updateSOSStatus(event, []string{models.SOSStatusOpen}, map[string]interface{}{
+		"status":          models.SOSStatusAcknowledged,
+		"acknowledged_by": actorID,
+		"acknowledged_at": now,
+	}, models.SOSEventLog{
+		SOSEventID:  event.ID,
+		Action:      models.SOSActionAcknowledged,
+		Level:       event.EscalationLevel,
+		ActorUserID: &actorID,
+		Note:        req.Note,
+	})
+
+	if !h.respondSOSUpdateError(c, err) {
+		return
+	}
+
+	// kabari guard bahwa bantuan sudah merespon
+	if err := notification.Send(h.DB, []int{int(event.UserID)}, "sos_acknowledged",
+		"SOS diterima", "Sinyal darurat Anda sudah diterima, bantuan segera datang",
+		models.JSONMap{"sos_event_id": event.ID}); err != nil {
+		log.Printf("⚠️ Gagal mengirim notifikasi acknowledge SOS %d: %v", event.ID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "SOS berhasil di-acknowledge",
+		"data": gin.H{
+			"id":              event.ID,
+			"status":          models.SOSStatusAcknowledged,
+			"acknowledged_by": user.ID,
+			"acknowledged_at": now,
+		},
+	})
+}
+
+// ResolveSOS - POST /api/v1/sos/:id/resolve
+func (h *SOSHandler) ResolveSOS(c *gin.Context) {
+	event, user, ok := h.loadSOS(c)
+	if !ok {
+		return
+	}
+
+	if !user.IsSupervisor() {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"message": "Hanya koordinator yang dapat menutup SOS",
+		})
+		return
+	}
+
+	var req struct {
+		Note string `json:"note"`
+	}
+	_ = c.ShouldBindJSON(&req)
+
+	now := time.Now()
+	actorID := uint(user.ID)
+
+	updates := map[string]interface{}{
+		"status":          models.SOSStatusResolved,
+		"resolved_by":     actorID,
+		"resolved_at":     now,
+		"resolution_note": req.Note,
+	}
+
+	// SOS yang langsung di-resolve tanpa acknowledge tetap dicatat siapa yang merespon
+	if event.AcknowledgedBy == nil {
+		updates["acknowledged_by"] = actorID
+		updates["acknowledged_at"] = now
+	}
+
+	err := h.updateSOSStatus(event, []string{models.SOSStatusOpen, models.SOSStatusAcknowledged}, updates,
+		models.SOSEventLog{
+			SOSEventID:  event.ID,
+			Action:      models.SOSActionResolved,
+			Level:       event.EscalationLevel,
+			ActorUserID: &actorID,
+			Note:        req.Note,
+		})
+
+	if !h.respondSOSUpdateError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "SOS berhasil ditutup",
+		"data": gin.H{
+			"id":          event.ID,
+			"status":      models.SOSStatusResolved,
+			"resolved_by": user.ID,
+			"resolved_at": now,
+		},
+	})
+}
+
+// updateSOSStatus mengubah status SOS dan mencatat log dalam satu transaksi
+func (h *SOSHandler) updateSOSStatus(event models.SOSEvent, fromStatuses []string, updates map[string]interface{}, entry models.SOSEventLog) error {
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.SOSEvent{}).
+			Where("id = ? AND status IN ?", event.ID, fromStatuses).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errSOSStatusChanged
+		}
+
+		return tx.Create(&entry).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	events.Publish(events.Event{
+		Type:     events.TypeSOSUpdated,
+		BranchID: int(event.BranchID),
+		UserID:   int(event.UserID),
+		Data: gin.H{
+			"sos_event_id": event.ID,
+			"status":       updates["status"],
+			"action":       entry.Action,
+			"actor":        entry.ActorUserID,
+		},
+	})
+
+	return nil
+}
+
+// respondSOSUpdateError menulis response error; mengembalikan true jika tidak ada error
+func (h *SOSHandler) respondSOSUpdateError(c *gin.Context, err error) bool {
+	if err == nil {
+		return true
+	}
+
+	if errors.Is(err, errSOSStatusChanged) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return false
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"status":  "error",
+		"message": "Gagal mengubah status SOS",
+		"error":   err.Error(),
+	})
+	return false
+}
+
+// loadSOS mengambil SOS dari path :id dan memastikan user punya akses
+func (h *SOSHandler) loadSOS(c *gin.Context) (models.SOSEvent, middleware.AuthUser, bool) {
+	var event models.SOSEvent
+
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  "error",
+			"message": "Unauthorized",
+		})
+		return event, user, false
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "id SOS tidak valid",
+		})
+		return event, user, false
+	}
+
+	err = h.DB.First(&event, id).Error
+	canAccess := event.UserID == uint(user.ID) || user.CanAccessBranch(int(event.BranchID))
+
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && !canAccess) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "SOS tidak ditemukan",
+		})
+		return event, user, false
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil SOS",
+			"error":   err.Error(),
+		})
+		return event, user, false
+	}
+
+	return event, user, true
+}
+
+// notifyLevel mengirim notifikasi ke penerima di level eskalasi tertentu dan mencatat lognya
+func (h *SOSHandler) notifyLevel(event models.SOSEvent, level int, action string) {
+	if level < 1 || level > len(sosEscalationChain) {
+		return
+	}
+
+	chain := sosEscalationChain[level-1]
+
+	var recipients []int
+	var err error
+	if chain.BranchOnly {
+		recipients, err = notification.BranchUsersByType(h.DB, int(event.BranchID), chain.UserTypes)
+	} else {
+		recipients, err = notification.UsersByType(h.DB, chain.UserTypes)
+	}
+
+	entry := models.SOSEventLog{
+		SOSEventID: event.ID,
+		Action:     action,
+		Level:      level,
+		Note:       chain.Name,
+		Data:       models.JSONMap{"recipients": recipients},
+	}
+
+	if err == nil {
+		var guard struct {
+			Name string
+		}
+		h.DB.Raw(`SELECT name FROM users WHERE id = ?`, event.UserID).Scan(&guard)
+
+		err = notification.Send(h.DB, recipients, "sos",
+			"🚨 SOS dari "+guard.Name,
+			fmt.Sprintf("Sinyal darurat di lokasi %.6f, %.6f. %s", event.Latitude, event.Longitude, event.Note),
+			models.JSONMap{
+				"sos_event_id": event.ID,
+				"latitude":     event.Latitude,
+				"longitude":    event.Longitude,
+				"level":        level,
+			})
+	}
+
+	if err != nil {
+		log.Printf("⚠️ Gagal mengirim notifikasi SOS %d level %d: %v", event.ID, level, err)
+		entry.Data["error"] = err.Error()
+	}
+
+	if err := h.DB.Create(&entry).Error; err != nil {
+		log.Printf("⚠️ Gagal mencatat log SOS %d: %v", event.ID, err)
+	}
+}
+
+// StartEscalationWorker menjalankan pengecekan eskalasi SOS di background sampai ctx dibatalkan
+func (h *SOSHandler) StartEscalationWorker(ctx context.Context) {
+	if h.DB == nil {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(30 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				h.escalateUnacknowledged()
+			}
+		}
+	}()
+}
+
+// escalateUnacknowledged menaikkan level SOS yang belum di-acknowledge melewati batas waktu
+func (h *SOSHandler) escalateUnacknowledged() {
+	deadline := time.Now().Add(-sosEscalationInterval())
+
+	var overdue []models.SOSEvent
+	if err := h.DB.Where("status = ? AND escalation_level < ? AND last_escalated_at <= ?",
+		models.SOSStatusOpen, len(sosEscalationChain), deadline).
+		Find(&overdue).Error; err != nil {
+		log.Printf("⚠️ Gagal mengambil SOS untuk eskalasi: %v", err)
+		return
+	}
+
+	for _, event := range overdue {
+		nextLevel := event.EscalationLevel + 1
+
+		// klaim eskalasi dengan kondisi level lama supaya tidak dobel jika ada lebih dari satu instance
+		result := h.DB.Model(&models.SOSEvent{}).
+			Where("id = ? AND status = ? AND escalation_level = ?", event.ID, models.SOSStatusOpen, event.EscalationLevel).
+			Updates(map[string]interface{}{
+				"escalation_level":  nextLevel,
+				"last_escalated_at": time.Now(),
+			})
+
+		if result.Error != nil {
+			log.Printf("⚠️ Gagal eskalasi SOS %d: %v", event.ID, result.Error)
+			continue
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		event.EscalationLevel = nextLevel
+		h.notifyLevel(event, nextLevel, models.SOSActionEscalated)
+	}
+}
@@ -0,0 +1,359 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"api_patroliku_docker/middleware"
+	"api_patroliku_docker/models"
+	"api_patroliku_docker/storage"
+	"api_patroliku_docker/tasks"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetChecklistItems - GET /api/v1/tasks/types/:id/checklist
+func (h *TaskHandler) GetChecklistItems(c *gin.Context) {
+	typeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || typeID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "ID tipe task tidak valid",
+		})
+		return
+	}
+
+	var items []models.TaskChecklistItem
+	if err := h.DB.Where("task_type_id = ? AND deleted_at IS NULL", typeID).
+		Order("sort_order ASC, id ASC").
+		Find(&items).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil checklist",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Checklist berhasil diambil",
+		"data":    items,
+	})
+}
+
+// CreateChecklistItem - POST /api/v1/tasks/types/:id/checklist (admin)
+func (h *TaskHandler) CreateChecklistItem(c *gin.Context) {
+	user, _ := middleware.CurrentUser(c)
+
+	typeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || typeID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "ID tipe task tidak valid",
+		})
+		return
+	}
+
+	var req models.TaskChecklistItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Data request tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	var typeCount int64
+	if err := h.DB.Raw(`SELECT COUNT(*) FROM task_type WHERE id = ?`, typeID).Scan(&typeCount).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil tipe task",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if typeCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Tipe task tidak ditemukan",
+		})
+		return
+	}
+
+	item := models.TaskChecklistItem{
+		TaskTypeID:  uint(typeID),
+		Label:       req.Label,
+		Description: req.Description,
+		Required:    req.Required == nil || *req.Required,
+		SortOrder:   req.SortOrder,
+		CreatedBy:   uint(user.ID),
+	}
+
+	// Required diisi eksplisit supaya false tidak diganti default kolom
+	if err := h.DB.Select("*").Omit("id", "deleted_at").Create(&item).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal menyimpan item checklist",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Item checklist berhasil dibuat",
+		"data":    item,
+	})
+}
+
+// UpdateChecklistItem - PUT /api/v1/tasks/checklist/:id (admin)
+func (h *TaskHandler) UpdateChecklistItem(c *gin.Context) {
+	item, ok := h.loadChecklistItem(c)
+	if !ok {
+		return
+	}
+
+	var req models.TaskChecklistItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Data request tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	item.Label = req.Label
+	item.Description = req.Description
+	item.SortOrder = req.SortOrder
+	if req.Required != nil {
+		item.Required = *req.Required
+	}
+
+	if err := h.DB.Model(&item).
+		Select("label", "description", "required", "sort_order", "updated_at").
+		Updates(&item).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengubah item checklist",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Item checklist berhasil diubah",
+		"data":    item,
+	})
+}
+
+// DeleteChecklistItem - DELETE /api/v1/tasks/checklist/:id (admin)
+// Jawaban yang sudah ada tetap tersimpan.
+func (h *TaskHandler) DeleteChecklistItem(c *gin.Context) {
+	item, ok := h.loadChecklistItem(c)
+	if !ok {
+		return
+	}
+
+	if err := h.DB.Model(&models.TaskChecklistItem{}).
+		Where("id = ?", item.ID).
+		Update("deleted_at", time.Now()).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal menghapus item checklist",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Item checklist berhasil dihapus",
+	})
+}
+
+// GetTaskChecklist - GET /api/v1/tasks/assign/:id/checklist
+func (h *TaskHandler) GetTaskChecklist(c *gin.Context) {
+	assign, _, ok := h.loadTaskAssign(c)
+	if !ok {
+		return
+	}
+
+	checklist, progress, err := tasks.Checklist(h.DB, assign.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil checklist",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Checklist berhasil diambil",
+		"data": gin.H{
+			"task_assign_id": assign.ID,
+			"checklist":      checklist,
+			"progress":       progress,
+		},
+	})
+}
+
+// AnswerChecklistItem - PUT /api/v1/tasks/assign/:id/checklist/:item_id (guard pemilik penugasan)
+// Body JSON atau multipart: result (pass/fail/na), comment, photo (opsional)
+func (h *TaskHandler) AnswerChecklistItem(c *gin.Context) {
+	assign, user, ok := h.loadTaskAssign(c)
+	if !ok {
+		return
+	}
+
+	if assign.UserTadID != uint(user.ID) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"message": "Hanya guard yang ditugaskan yang dapat mengisi checklist",
+		})
+		return
+	}
+
+	if !tasks.IsOpen(assign.Status) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"message": "Task sudah " + assign.Status + ", checklist tidak bisa diubah",
+		})
+		return
+	}
+
+	itemID, err := strconv.Atoi(c.Param("item_id"))
+	if err != nil || itemID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "ID item checklist tidak valid",
+		})
+		return
+	}
+
+	var req models.TaskChecklistAnswerRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Data request tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// item harus milik tipe task penugasan ini
+	var itemCount int64
+	h.DB.Raw(`
+		SELECT COUNT(*)
+		FROM task_checklist_item ci
+		JOIN task t ON t.task_type_id = ci.task_type_id
+		WHERE ci.id = ? AND t.id = ? AND ci.deleted_at IS NULL
+	`, itemID, assign.TaskID).Scan(&itemCount)
+	if itemCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Item checklist tidak ditemukan untuk task ini",
+		})
+		return
+	}
+
+	result := models.TaskChecklistResult{
+		TaskAssignID:    assign.ID,
+		ChecklistItemID: uint(itemID),
+		Result:          req.Result,
+		Comment:         req.Comment,
+		AnsweredBy:      uint(user.ID),
+	}
+	updateColumns := []string{"result", "comment", "answered_by", "updated_at"}
+
+	// ===== foto opsional =====
+	if fileHeader, err := c.FormFile("photo"); err == nil && fileHeader != nil {
+		if !storage.IsImage(fileHeader) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "File photo harus berupa gambar",
+			})
+			return
+		}
+
+		photoURL, err := storage.SaveUploadedFile(c, fileHeader, "task_checklist")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"message": "Gagal menyimpan foto checklist",
+				"error":   err.Error(),
+			})
+			return
+		}
+		result.Photo = photoURL
+		updateColumns = append(updateColumns, "photo")
+	}
+
+	if err := h.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "task_assign_id"}, {Name: "checklist_item_id"}},
+		DoUpdates: clause.AssignmentColumns(updateColumns),
+	}).Create(&result).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal menyimpan jawaban checklist",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	_, progress, _ := tasks.Checklist(h.DB, assign.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Jawaban checklist berhasil disimpan",
+		"data": gin.H{
+			"item_id":  itemID,
+			"result":   req.Result,
+			"comment":  req.Comment,
+			"photo":    result.Photo,
+			"progress": progress,
+		},
+	})
+}
+
+// loadChecklistItem mengambil item checklist dari path :id
+func (h *TaskHandler) loadChecklistItem(c *gin.Context) (models.TaskChecklistItem, bool) {
+	var item models.TaskChecklistItem
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "ID item checklist tidak valid",
+		})
+		return item, false
+	}
+
+	err = h.DB.Where("id = ? AND deleted_at IS NULL", id).First(&item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Item checklist tidak ditemukan",
+		})
+		return item, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil item checklist",
+			"error":   err.Error(),
+		})
+		return item, false
+	}
+
+	return item, true
+}
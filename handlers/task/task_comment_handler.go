@@ -0,0 +1,362 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+
+	"api_patroliku_docker/events"
+	"api_patroliku_docker/middleware"
+	"api_patroliku_docker/models"
+	"api_patroliku_docker/notification"
+	"api_patroliku_docker/storage"
+	"api_patroliku_docker/tasks"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxCommentImages batas jumlah gambar per komentar
+const maxCommentImages = 5
+
+// taskCommentEntry satu komentar di thread beserta penulis dan pembacanya
+type taskCommentEntry struct {
+	ID        uint                  `json:"id"`
+	UserID    uint                  `json:"user_id"`
+	UserName  *string               `json:"user_name"`
+	Comment   string                `json:"comment"`
+	Images    models.JSONStringList `json:"images"`
+	CreatedAt time.Time             `json:"created_at"`
+	ReadBy    []taskCommentReader   `json:"read_by" gorm:"-"`
+}
+
+type taskCommentReader struct {
+	UserID            uint      `json:"user_id"`
+	UserName          *string   `json:"user_name"`
+	LastReadCommentID uint      `json:"-"`
+	ReadAt            time.Time `json:"read_at"`
+}
+
+// GetTaskComments - GET /api/v1/tasks/assign/:id/comments
+func (h *TaskHandler) GetTaskComments(c *gin.Context) {
+	assign, user, ok := h.loadTaskAssign(c)
+	if !ok {
+		return
+	}
+
+	thread, err := h.commentThread(assign.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil komentar",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Komentar berhasil diambil",
+		"data": gin.H{
+			"task_assign_id": assign.ID,
+			"comments":       thread,
+			"unread":         unreadComments(thread, uint(user.ID)),
+		},
+	})
+}
+
+// AddTaskComment - POST /api/v1/tasks/assign/:id/comments
+// Body JSON atau multipart: comment, images[] (opsional, maksimal 5 gambar)
+func (h *TaskHandler) AddTaskComment(c *gin.Context) {
+	assign, user, ok := h.loadTaskAssign(c)
+	if !ok {
+		return
+	}
+
+	var req models.TaskCommentRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Data request tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
+	req.Comment = strings.TrimSpace(req.Comment)
+
+	// ===== gambar opsional =====
+	files, err := commentImageFiles(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if req.Comment == "" && len(files) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "comment atau images wajib diisi",
+		})
+		return
+	}
+
+	images := models.JSONStringList{}
+	for _, fh := range files {
+		url, err := storage.SaveUploadedFile(c, fh, "task_comment")
+		if err != nil {
+			for _, saved := range images {
+				storage.RemovePublicFile(saved)
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"message": "Gagal menyimpan gambar komentar",
+				"error":   err.Error(),
+			})
+			return
+		}
+		images = append(images, url)
+	}
+
+	comment := models.TaskAssignComment{
+		TaskAssignID: assign.ID,
+		UserID:       uint(user.ID),
+		Comment:      req.Comment,
+		Images:       images,
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&comment).Error; err != nil {
+			return err
+		}
+		// penulis otomatis sudah membaca komentarnya sendiri
+		return markCommentsRead(tx, assign.ID, uint(user.ID), comment.ID)
+	})
+	if err != nil {
+		for _, saved := range images {
+			storage.RemovePublicFile(saved)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal menyimpan komentar",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	h.notifyComment(assign, user, comment)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Komentar berhasil disimpan",
+		"data":    comment,
+	})
+}
+
+// MarkTaskCommentsRead - POST /api/v1/tasks/assign/:id/comments/read
+func (h *TaskHandler) MarkTaskCommentsRead(c *gin.Context) {
+	assign, user, ok := h.loadTaskAssign(c)
+	if !ok {
+		return
+	}
+
+	var req models.TaskCommentReadRequest
+	_ = c.ShouldBindJSON(&req)
+
+	// tanpa last_comment_id berarti semua komentar sampai saat ini
+	var lastID uint
+	h.DB.Model(&models.TaskAssignComment{}).
+		Select("COALESCE(MAX(id), 0)").
+		Where("task_assign_id = ?", assign.ID).
+		Scan(&lastID)
+	if req.LastCommentID > 0 && req.LastCommentID < lastID {
+		lastID = req.LastCommentID
+	}
+
+	if err := markCommentsRead(h.DB, assign.ID, uint(user.ID), lastID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal menandai komentar dibaca",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Komentar ditandai sudah dibaca",
+		"data": gin.H{
+			"task_assign_id":       assign.ID,
+			"last_read_comment_id": lastID,
+		},
+	})
+}
+
+// commentImageFiles gambar dari multipart field "images[]" / "images"
+func commentImageFiles(c *gin.Context) ([]*multipart.FileHeader, error) {
+	if !strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		return nil, nil
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, err
+	}
+
+	files := append(form.File["images[]"], form.File["images"]...)
+
+	if len(files) > maxCommentImages {
+		return nil, fmt.Errorf("maksimal %d gambar per komentar", maxCommentImages)
+	}
+
+	for _, file := range files {
+		if !storage.IsImage(file) {
+			return nil, fmt.Errorf("file %s harus berupa gambar", file.Filename)
+		}
+	}
+
+	return files, nil
+}
+
+// commentThread semua komentar penugasan, urut dari yang terlama, dengan daftar pembaca tiap komentar
+func (h *TaskHandler) commentThread(assignID uint) ([]taskCommentEntry, error) {
+	thread := []taskCommentEntry{}
+	if err := h.DB.Raw(`
+		SELECT c.id, c.user_id, u.name AS user_name, c.comment, c.images, c.created_at
+		FROM task_assign_comment c
+		LEFT JOIN users u ON u.id = c.user_id
+		WHERE c.task_assign_id = ?
+		ORDER BY c.id ASC
+	`, assignID).Scan(&thread).Error; err != nil {
+		return nil, err
+	}
+
+	var readers []taskCommentReader
+	if err := h.DB.Raw(`
+		SELECT r.user_id, u.name AS user_name, r.last_read_comment_id, r.read_at
+		FROM task_assign_comment_read r
+		LEFT JOIN users u ON u.id = r.user_id
+		WHERE r.task_assign_id = ?
+	`, assignID).Scan(&readers).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range thread {
+		thread[i].ReadBy = []taskCommentReader{}
+		if thread[i].Images == nil {
+			thread[i].Images = models.JSONStringList{}
+		}
+		for _, reader := range readers {
+			if reader.UserID != thread[i].UserID && reader.LastReadCommentID >= thread[i].ID {
+				thread[i].ReadBy = append(thread[i].ReadBy, reader)
+			}
+		}
+	}
+
+	return thread, nil
+}
+
+// unreadComments jumlah komentar orang lain yang belum dibaca user
+func unreadComments(thread []taskCommentEntry, userID uint) int {
+	unread := 0
+	for _, entry := range thread {
+		if entry.UserID == userID {
+			continue
+		}
+		read := false
+		for _, reader := range entry.ReadBy {
+			if reader.UserID == userID {
+				read = true
+				break
+			}
+		}
+		if !read {
+			unread++
+		}
+	}
+	return unread
+}
+
+// markCommentsRead menyimpan read receipt user, tidak pernah mundur ke komentar yang lebih lama
+func markCommentsRead(db *gorm.DB, assignID, userID, lastCommentID uint) error {
+	receipt := models.TaskAssignCommentRead{
+		TaskAssignID:      assignID,
+		UserID:            userID,
+		LastReadCommentID: lastCommentID,
+		ReadAt:            time.Now(),
+	}
+
+	return db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "task_assign_id"}, {Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"last_read_comment_id": gorm.Expr("GREATEST(task_assign_comment_read.last_read_comment_id, EXCLUDED.last_read_comment_id)"),
+			"read_at":              gorm.Expr("EXCLUDED.read_at"),
+		}),
+	}).Create(&receipt).Error
+}
+
+// notifyComment mengabari peserta thread: guard pemilik, koordinator branch (jika penulis guard)
+// dan semua yang pernah berkomentar, kecuali penulis
+func (h *TaskHandler) notifyComment(assign models.TaskAssign, author middleware.AuthUser, comment models.TaskAssignComment) {
+	branchID, err := tasks.AssignBranchID(h.DB, int(assign.ID))
+	if err != nil {
+		log.Printf("⚠️ Gagal mengambil branch task assign %d: %v", assign.ID, err)
+	}
+
+	recipients := []int{int(assign.UserTadID)}
+
+	var commenters []int
+	h.DB.Model(&models.TaskAssignComment{}).
+		Distinct("user_id").
+		Where("task_assign_id = ?", assign.ID).
+		Pluck("user_id", &commenters)
+	recipients = append(recipients, commenters...)
+
+	if assign.UserTadID == uint(author.ID) && branchID > 0 {
+		coordinators, err := notification.BranchUsersByType(h.DB, branchID, middleware.CoordinatorUserTypes)
+		if err != nil {
+			log.Printf("⚠️ Gagal mengambil koordinator branch %d: %v", branchID, err)
+		}
+		recipients = append(recipients, coordinators...)
+	}
+
+	filtered := make([]int, 0, len(recipients))
+	for _, id := range recipients {
+		if id != author.ID {
+			filtered = append(filtered, id)
+		}
+	}
+
+	var authorName string
+	h.DB.Raw(`SELECT name FROM users WHERE id = ?`, author.ID).Scan(&authorName)
+
+	body := comment.Comment
+	if body == "" {
+		body = "Mengirim gambar"
+	}
+
+	if len(filtered) > 0 {
+		if err := notification.Send(h.DB, filtered, "task_comment",
+			"Komentar baru dari "+authorName, body,
+			models.JSONMap{"task_assign_id": assign.ID, "comment_id": comment.ID}); err != nil {
+			log.Printf("⚠️ Gagal mengirim notifikasi komentar task %d: %v", assign.ID, err)
+		}
+	}
+
+	events.Publish(events.Event{
+		Type:     events.TypeTaskComment,
+		BranchID: branchID,
+		UserID:   author.ID,
+		Data: gin.H{
+			"task_assign_id": assign.ID,
+			"comment_id":     comment.ID,
+			"comment":        comment.Comment,
+			"images":         comment.Images,
+		},
+	})
+}
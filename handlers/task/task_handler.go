@@ -4,8 +4,13 @@ import (
 	"math"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"api_patroliku_docker/database"
+	"api_patroliku_docker/middleware"
+	"api_patroliku_docker/models"
+	"api_patroliku_docker/tasks"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -21,121 +26,63 @@ func NewTaskHandler() *TaskHandler {
 	}
 }
 
-func (h *TaskHandler) GetTaskByUser(c *gin.Context) {
-	userIDStr := c.Query("user_id")
-	if userIDStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  "error",
-			"message": "user_id wajib diisi",
-		})
-		return
-	}
-
-	// ===== user_id dari param =====
-	userID, err := strconv.Atoi(userIDStr)
-	if err != nil || userID <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
+// GetTask - GET /api/v1/tasks
+// Inbox task milik user login. Filter: status (bisa dipisah koma), task_type_id, start_date, end_date, sort (due / -due)
+func (h *TaskHandler) GetTask(c *gin.Context) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
 			"status":  "error",
-			"message": "user_id tidak valid",
+			"message": "Unauthorized",
 		})
 		return
 	}
 
-	// ===== pagination =====
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 {
-		limit = 10
-	}
-
-	offset := (page - 1) * limit
-
-	// ===== total data =====
-	var total int64
-	countQuery := `
-		SELECT COUNT(*)
-		FROM task_assign ta 
-		LEFT JOIN task t ON ta.task_id = t.id 
-		LEFT JOIN task_type tt ON tt.id = t.task_type_id 
-		WHERE ta.user_tad_id = ? AND t.deleted_at IS NULL
-	`
-
-	if err := h.DB.Raw(countQuery, userID).Scan(&total).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  "error",
-			"message": "Gagal menghitung data",
-			"error":   err.Error(),
-		})
-		return
-	}
+	query := h.taskAssignQuery().Where("ta.user_tad_id = ?", user.ID)
 
-	// ===== data list =====
+	h.listTaskAssigns(c, query, gin.H{"user_id": user.ID})
+}
 
-	// Buat struct untuk response baru
-	type TaskAssignResponse struct {
-		ID        int     `json:"id"`
-		Status    string  `json:"status"`
-		TitleTask string  `json:"title_task"`
-		Type      string  `json:"type"`
-		Note      *string `json:"note"`
-		StartTime *string `json:"start_time"`
-		EndTime   *string `json:"end_time"`
+// GetBranchTask - GET /api/v1/tasks/branch (koordinator)
+// Semua penugasan di branch koordinator, admin bisa memilih branch_id. Filter tambahan: user_tad_id
+func (h *TaskHandler) GetBranchTask(c *gin.Context) {
+	user, _ := middleware.CurrentUser(c)
+
+	branchID := user.BranchID
+	if raw := c.Query("branch_id"); raw != "" && user.IsAdmin() {
+		id, err := strconv.Atoi(raw)
+		if err != nil || id <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "branch_id tidak valid",
+			})
+			return
+		}
+		branchID = id
 	}
 
-	var taskAssigns []TaskAssignResponse
-
-	dataQuery := `
-		SELECT 
-			ta.id,
-			ta.status,
-			t.name AS title_task,
-			tt.name AS type,
-			ta.note,
-			ta.start_time,
-			ta.end_time
-		FROM task_assign ta 
-		LEFT JOIN task t ON ta.task_id = t.id 
-		LEFT JOIN task_type tt ON tt.id = t.task_type_id 
-		WHERE ta.user_tad_id = ? AND t.deleted_at IS NULL
-		ORDER BY ta.id DESC
-		LIMIT ? OFFSET ?
-	`
-
-	if err := h.DB.Raw(dataQuery, userID, limit, offset).
-		Scan(&taskAssigns).Error; err != nil {
+	query := h.taskAssignQuery().
+		Where("COALESCE(NULLIF(t.branch_id, 0), uti.branch_id) = ?", branchID)
 
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  "error",
-			"message": "Gagal mengambil task assign",
-			"error":   err.Error(),
-		})
-		return
+	if userTadID := c.Query("user_tad_id"); userTadID != "" {
+		query = query.Where("ta.user_tad_id = ?", userTadID)
 	}
 
-	totalPage := int(math.Ceil(float64(total) / float64(limit)))
-
-	// ===== response =====
-	c.JSON(http.StatusOK, gin.H{
-		"status":  "success",
-		"message": "Task assign berhasil diambil",
-		"data":    taskAssigns,
-		"metadata": gin.H{
-			"user_id":    userID,
-			"page":       page,
-			"limit":      limit,
-			"total":      total,
-			"total_page": totalPage,
-			"has_data":   len(taskAssigns) > 0,
-		},
-	})
+	h.listTaskAssigns(c, query, gin.H{"branch_id": branchID})
 }
 
-func (h *TaskHandler) GetTask(c *gin.Context) {
+// taskAssignQuery query dasar daftar task_assign (alias ta, t, tt, u, uti)
+func (h *TaskHandler) taskAssignQuery() *gorm.DB {
+	return h.DB.Table("task_assign ta").
+		Joins("LEFT JOIN task t ON ta.task_id = t.id").
+		Joins("LEFT JOIN task_type tt ON tt.id = t.task_type_id").
+		Joins("LEFT JOIN users u ON u.id = ta.user_tad_id").
+		Joins("LEFT JOIN user_tad_information uti ON uti.user_id = ta.user_tad_id").
+		Where("t.deleted_at IS NULL")
+}
 
+// listTaskAssigns menerapkan filter, sorting & pagination lalu mengirim response
+func (h *TaskHandler) listTaskAssigns(c *gin.Context, query *gorm.DB, metadata gin.H) {
 	// ===== pagination =====
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
@@ -149,19 +96,57 @@ func (h *TaskHandler) GetTask(c *gin.Context) {
 
 	offset := (page - 1) * limit
 
-	// ===== total data =====
-	var total int64
-	countQuery := `
-		SELECT COUNT(*)
-		FROM task_assign ta 
-		LEFT JOIN task t ON ta.task_id = t.id 
-		LEFT JOIN task_type tt ON tt.id = t.task_type_id 
-		WHERE t.deleted_at IS NULL
-	`
+	// ===== filter =====
+	if status := c.Query("status"); status != "" {
+		statuses := []string{}
+		for _, s := range strings.Split(status, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				statuses = append(statuses, s)
+			}
+		}
+		query = query.Where("ta.status IN ?", statuses)
+	}
+
+	if taskTypeID := c.Query("task_type_id"); taskTypeID != "" {
+		query = query.Where("t.task_type_id = ?", taskTypeID)
+	}
 
-	if err := h.DB.Raw(countQuery).
-		Scan(&total).Error; err != nil {
+	// penugasan yang jendela waktunya beririsan dengan rentang tanggal
+	if startDate := c.Query("start_date"); startDate != "" {
+		t, err := time.ParseInLocation("2006-01-02", startDate, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "format start_date harus YYYY-MM-DD",
+			})
+			return
+		}
+		query = query.Where("(ta.end_time IS NULL OR ta.end_time >= ?)", t)
+	}
+	if endDate := c.Query("end_date"); endDate != "" {
+		t, err := time.ParseInLocation("2006-01-02", endDate, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "format end_date harus YYYY-MM-DD",
+			})
+			return
+		}
+		query = query.Where("(ta.start_time IS NULL OR ta.start_time < ?)", t.AddDate(0, 0, 1))
+	}
+
+	// ===== sorting =====
+	order := "ta.id DESC"
+	switch c.Query("sort") {
+	case "due":
+		order = "ta.end_time ASC NULLS LAST, ta.id DESC"
+	case "-due":
+		order = "ta.end_time DESC NULLS LAST, ta.id DESC"
+	}
 
+	// ===== total data =====
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
 			"message": "Gagal menghitung data",
@@ -172,35 +157,41 @@ func (h *TaskHandler) GetTask(c *gin.Context) {
 
 	// ===== data list =====
 	type TaskAssignResponse struct {
-		ID        int     `json:"id"`
-		Status    string  `json:"status"`
-		TitleTask string  `json:"title_task"`
-		Type      string  `json:"type"`
-		Note      *string `json:"note"`
-		StartTime *string `json:"start_time"`
-		EndTime   *string `json:"end_time"`
+		ID         int     `json:"id"`
+		Status     string  `json:"status"`
+		TitleTask  string  `json:"title_task"`
+		Type       string  `json:"type"`
+		TaskTypeID *int    `json:"task_type_id"`
+		UserTadID  int     `json:"user_tad_id"`
+		UserName   *string `json:"user_name"`
+		Note       *string `json:"note"`
+		StartTime  *string `json:"start_time"`
+		EndTime    *string `json:"end_time"`
+
+		ReviewScore *int    `json:"review_score"`
+		ReviewNote  *string `json:"review_note"` // alasan penolakan jika status rejected
 	}
 
 	var taskAssigns []TaskAssignResponse
 
-	dataQuery := `
-		SELECT 
+	if err := query.
+		Select(`
 			ta.id,
 			ta.status,
 			t.name AS title_task,
 			tt.name AS type,
+			t.task_type_id,
+			ta.user_tad_id,
+			u.name AS user_name,
 			ta.note,
 			ta.start_time,
-			ta.end_time
-		FROM task_assign ta 
-		LEFT JOIN task t ON ta.task_id = t.id 
-		LEFT JOIN task_type tt ON tt.id = t.task_type_id 
-		WHERE t.deleted_at IS NULL
-		ORDER BY ta.id DESC
-		LIMIT ? OFFSET ?
-	`
-
-	if err := h.DB.Raw(dataQuery, limit, offset).
+			ta.end_time,
+			ta.review_score,
+			ta.review_note
+		`).
+		Order(order).
+		Limit(limit).
+		Offset(offset).
 		Scan(&taskAssigns).Error; err != nil {
 
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -213,38 +204,25 @@ func (h *TaskHandler) GetTask(c *gin.Context) {
 
 	totalPage := int(math.Ceil(float64(total) / float64(limit)))
 
+	metadata["page"] = page
+	metadata["limit"] = limit
+	metadata["total"] = total
+	metadata["total_page"] = totalPage
+	metadata["has_data"] = len(taskAssigns) > 0
+
 	// ===== response =====
 	c.JSON(http.StatusOK, gin.H{
-		"status":  "success",
-		"message": "Task assign berhasil diambil",
-		"data":    taskAssigns,
-		"metadata": gin.H{
-			"page":       page,
-			"limit":      limit,
-			"total":      total,
-			"total_page": totalPage,
-			"has_data":   len(taskAssigns) > 0,
-		},
+		"status":   "success",
+		"message":  "Task assign berhasil diambil",
+		"data":     taskAssigns,
+		"metadata": metadata,
 	})
 }
 
 func (h *TaskHandler) GetTaskDetail(c *gin.Context) {
-	// ===== Get task_assign_id dari path parameter =====
-	taskAssignIDStr := c.Param("id")
-	if taskAssignIDStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  "error",
-			"message": "ID task wajib diisi",
-		})
-		return
-	}
-
-	taskAssignID, err := strconv.Atoi(taskAssignIDStr)
-	if err != nil || taskAssignID <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  "error",
-			"message": "ID task tidak valid",
-		})
+	// ===== Penugasan: guard pemilik atau koordinator di branch task =====
+	assign, _, ok := h.loadTaskAssign(c)
+	if !ok {
 		return
 	}
 
@@ -257,6 +235,16 @@ func (h *TaskHandler) GetTaskDetail(c *gin.Context) {
 		Note      *string `json:"note"`
 		StartTime *string `json:"start_time"`
 		EndTime   *string `json:"end_time"`
+
+		// evidence pengerjaan
+		BeforePhotos   models.JSONStringList `json:"before_photos"`
+		AfterPhotos    models.JSONStringList `json:"after_photos"`
+		NotePengerjaan *string               `json:"note_pengerjaan"`
+		TaskCondition  *string               `json:"task_condition"`
+
+		Checklist         []tasks.ChecklistEntry  `json:"checklist" gorm:"-"`
+		ChecklistProgress tasks.ChecklistProgress `json:"checklist_progress" gorm:"-"`
+		Comments          []taskCommentEntry      `json:"comments" gorm:"-"`
 	}
 
 	var taskDetail TaskDetailResponse
@@ -271,9 +259,10 @@ func (h *TaskHandler) GetTaskDetail(c *gin.Context) {
 			ta.note,
 			ta.start_time,
 			ta.end_time,
-			ta.end_time, 
-			te.before_photos, 
-			te.note  as note_pengerjaan
+			te.before_photos,
+			te.after_photos,
+			te.note AS note_pengerjaan,
+			te.task_condition
 		FROM task_assign ta 
 		LEFT JOIN task t ON ta.task_id = t.id 
 		LEFT JOIN task_type tt ON tt.id = t.task_type_id 
@@ -282,7 +271,7 @@ func (h *TaskHandler) GetTaskDetail(c *gin.Context) {
 		LIMIT 1
 	`
 
-	if err := h.DB.Raw(detailQuery, taskAssignID).
+	if err := h.DB.Raw(detailQuery, assign.ID).
 		Scan(&taskDetail).Error; err != nil {
 
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -302,6 +291,36 @@ func (h *TaskHandler) GetTaskDetail(c *gin.Context) {
 		return
 	}
 
+	if taskDetail.BeforePhotos == nil {
+		taskDetail.BeforePhotos = models.JSONStringList{}
+	}
+	if taskDetail.AfterPhotos == nil {
+		taskDetail.AfterPhotos = models.JSONStringList{}
+	}
+
+	// ===== Checklist & progres =====
+	var err error
+	taskDetail.Checklist, taskDetail.ChecklistProgress, err = tasks.Checklist(h.DB, uint(taskDetail.ID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil checklist task",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// ===== Thread komentar =====
+	taskDetail.Comments, err = h.commentThread(uint(taskDetail.ID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil komentar task",
+			"error":   err.Error(),
+		})
+		return
+	}
+
 	// ===== Response =====
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
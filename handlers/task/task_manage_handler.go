@@ -0,0 +1,627 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"api_patroliku_docker/middleware"
+	"api_patroliku_docker/models"
+	"api_patroliku_docker/notification"
+	"api_patroliku_docker/tasks"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetTaskTypes - GET /api/v1/tasks/types
+func (h *TaskHandler) GetTaskTypes(c *gin.Context) {
+	type TaskTypeResponse struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	var data []TaskTypeResponse
+	if err := h.DB.Raw(`SELECT id, name FROM task_type ORDER BY name ASC`).
+		Scan(&data).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil tipe task",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Tipe task berhasil diambil",
+		"data":    data,
+	})
+}
+
+// CreateTask - POST /api/v1/tasks (koordinator)
+// Jika user_tad_ids diisi, task langsung ditugaskan dengan start_time & end_time.
+func (h *TaskHandler) CreateTask(c *gin.Context) {
+	user, _ := middleware.CurrentUser(c)
+
+	var req models.TaskCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Data request tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// ===== branch task =====
+	branchID := user.BranchID
+	if req.BranchID != 0 {
+		if !user.CanAccessBranch(int(req.BranchID)) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"status":  "error",
+				"message": "Tidak memiliki akses ke branch ini",
+			})
+			return
+		}
+		branchID = int(req.BranchID)
+	}
+
+	if branchID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "branch_id wajib diisi",
+		})
+		return
+	}
+
+	var typeCount int64
+	h.DB.Raw(`SELECT COUNT(*) FROM task_type WHERE id = ?`, req.TaskTypeID).Scan(&typeCount)
+	if typeCount == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "task_type_id tidak ditemukan",
+		})
+		return
+	}
+
+	// ===== penugasan langsung (opsional) =====
+	var startTime, endTime time.Time
+	if len(req.UserTadIDs) > 0 {
+		var err error
+		startTime, endTime, err = tasks.ParseWindow(req.StartTime, req.EndTime)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": err.Error(),
+			})
+			return
+		}
+
+		if err := h.validateGuards(req.UserTadIDs, branchID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": err.Error(),
+			})
+			return
+		}
+	}
+
+	task := models.Task{
+		Name:        req.Name,
+		TaskTypeID:  req.TaskTypeID,
+		BranchID:    uint(branchID),
+		Description: req.Description,
+		CreatedBy:   uint(user.ID),
+	}
+
+	var assigns []models.TaskAssign
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&task).Error; err != nil {
+			return err
+		}
+
+		if len(req.UserTadIDs) == 0 {
+			return nil
+		}
+
+		assigns = newTaskAssigns(task.ID, req.UserTadIDs, startTime, endTime, req.Note, user.ID)
+		return createTaskAssigns(tx, assigns, user.ID)
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal menyimpan task",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	h.notifyAssigned(task, assigns)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Task berhasil dibuat",
+		"data": gin.H{
+			"task":    task,
+			"assigns": assigns,
+		},
+	})
+}
+
+// AssignTask - POST /api/v1/tasks/:id/assign (koordinator)
+func (h *TaskHandler) AssignTask(c *gin.Context) {
+	user, _ := middleware.CurrentUser(c)
+
+	taskID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || taskID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "ID task tidak valid",
+		})
+		return
+	}
+
+	var req models.TaskAssignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Data request tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	var task models.Task
+	err = h.DB.Where("id = ? AND deleted_at IS NULL", taskID).First(&task).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Task tidak ditemukan",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil task",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// task lama tanpa branch mengikuti branch koordinator
+	branchID := int(task.BranchID)
+	if branchID == 0 {
+		branchID = user.BranchID
+	}
+
+	if !user.CanAccessBranch(branchID) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"message": "Tidak memiliki akses ke task ini",
+		})
+		return
+	}
+
+	startTime, endTime, err := tasks.ParseWindow(req.StartTime, req.EndTime)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := h.validateGuards(req.UserTadIDs, branchID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	assigns := newTaskAssigns(task.ID, req.UserTadIDs, startTime, endTime, req.Note, user.ID)
+	if err := h.DB.Transaction(func(tx *gorm.DB) error {
+		return createTaskAssigns(tx, assigns, user.ID)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal menyimpan penugasan",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	h.notifyAssigned(task, assigns)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Task berhasil ditugaskan",
+		"data":    assigns,
+	})
+}
+
+// ReassignTask - POST /api/v1/tasks/assign/:id/reassign (koordinator)
+func (h *TaskHandler) ReassignTask(c *gin.Context) {
+	assign, user, ok := h.loadTaskAssign(c)
+	if !ok {
+		return
+	}
+
+	var req models.TaskReassignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Data request tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if assign.Status != models.TaskStatusAssigned {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"message": "Hanya penugasan yang belum dikerjakan yang bisa dipindahkan",
+		})
+		return
+	}
+
+	branchID, err := tasks.AssignBranchID(h.DB, int(assign.ID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil branch task",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err := h.validateGuards([]uint{req.UserTadID}, branchID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	updates := map[string]interface{}{
+		"user_tad_id": req.UserTadID,
+		"assigned_by": user.ID,
+		"updated_at":  time.Now(),
+	}
+
+	if req.StartTime != "" || req.EndTime != "" {
+		startTime, endTime, err := tasks.ParseWindow(req.StartTime, req.EndTime)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": err.Error(),
+			})
+			return
+		}
+		updates["start_time"] = startTime
+		updates["end_time"] = endTime
+	}
+
+	if req.Note != "" {
+		updates["note"] = req.Note
+	}
+
+	result := h.DB.Model(&models.TaskAssign{}).
+		Where("id = ? AND status = ? AND user_tad_id = ?", assign.ID, assign.Status, assign.UserTadID).
+		Updates(updates)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal memindahkan penugasan",
+			"error":   result.Error.Error(),
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"message": tasks.ErrStatusChanged.Error(),
+		})
+		return
+	}
+
+	previousUser := assign.UserTadID
+	h.DB.First(&assign, assign.ID)
+
+	h.notifyUsers([]uint{assign.UserTadID}, "task_assigned",
+		"Tugas baru", "Anda mendapat tugas yang dipindahkan kepada Anda", assign.ID)
+	h.notifyUsers([]uint{previousUser}, "task_reassigned",
+		"Tugas dipindahkan", "Tugas Anda telah dipindahkan ke guard lain", assign.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Penugasan berhasil dipindahkan",
+		"data":    assign,
+	})
+}
+
+// CancelTaskAssign - POST /api/v1/tasks/assign/:id/cancel (koordinator)
+func (h *TaskHandler) CancelTaskAssign(c *gin.Context) {
+	assign, user, ok := h.loadTaskAssign(c)
+	if !ok {
+		return
+	}
+
+	var req models.TaskStatusRequest
+	_ = c.ShouldBindJSON(&req)
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		_, err := tasks.Transition(tx, assign.ID, models.TaskStatusCancelled, user.ID, req.Note)
+		return err
+	})
+	if err != nil {
+		respondTransitionError(c, err)
+		return
+	}
+
+	h.notifyUsers([]uint{assign.UserTadID}, "task_cancelled",
+		"Tugas dibatalkan", "Salah satu tugas Anda dibatalkan. "+req.Note, assign.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Penugasan berhasil dibatalkan",
+		"data": gin.H{
+			"id":     assign.ID,
+			"status": models.TaskStatusCancelled,
+		},
+	})
+}
+
+// StartTask - POST /api/v1/tasks/assign/:id/start (guard pemilik penugasan)
+func (h *TaskHandler) StartTask(c *gin.Context) {
+	assign, user, ok := h.loadTaskAssign(c)
+	if !ok {
+		return
+	}
+
+	if assign.UserTadID != uint(user.ID) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"message": "Hanya guard yang ditugaskan yang dapat memulai task",
+		})
+		return
+	}
+
+	var req models.TaskStatusRequest
+	_ = c.ShouldBindJSON(&req)
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		_, err := tasks.Transition(tx, assign.ID, models.TaskStatusInProgress, user.ID, req.Note)
+		return err
+	})
+	if err != nil {
+		respondTransitionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Task dimulai",
+		"data": gin.H{
+			"id":     assign.ID,
+			"status": models.TaskStatusInProgress,
+		},
+	})
+}
+
+// GetTaskAssignHistory - GET /api/v1/tasks/assign/:id/history
+func (h *TaskHandler) GetTaskAssignHistory(c *gin.Context) {
+	assign, _, ok := h.loadTaskAssign(c)
+	if !ok {
+		return
+	}
+
+	type HistoryResponse struct {
+		ID         int       `json:"id"`
+		FromStatus string    `json:"from_status"`
+		ToStatus   string    `json:"to_status"`
+		ActorID    *int      `json:"actor_id"`
+		ActorName  *string   `json:"actor_name"`
+		Note       string    `json:"note"`
+		CreatedAt  time.Time `json:"created_at"`
+	}
+
+	var history []HistoryResponse
+	if err := h.DB.Raw(`
+		SELECT
+			h.id,
+			h.from_status,
+			h.to_status,
+			h.actor_id,
+			u.name AS actor_name,
+			h.note,
+			h.created_at
+		FROM task_assign_history h
+		LEFT JOIN users u ON u.id = h.actor_id
+		WHERE h.task_assign_id = ?
+		ORDER BY h.created_at ASC, h.id ASC
+	`, assign.ID).Scan(&history).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil riwayat task",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Riwayat task berhasil diambil",
+		"data": gin.H{
+			"task_assign_id": assign.ID,
+			"status":         assign.Status,
+			"next_statuses":  tasks.NextStatuses(assign.Status),
+			"sla":            h.taskSLA(assign.ID),
+			"history":        history,
+		},
+	})
+}
+
+// loadTaskAssign mengambil task_assign dari path :id dan memastikan user boleh mengakses branch-nya
+func (h *TaskHandler) loadTaskAssign(c *gin.Context) (models.TaskAssign, middleware.AuthUser, bool) {
+	var assign models.TaskAssign
+
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  "error",
+			"message": "Unauthorized",
+		})
+		return assign, user, false
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "ID task assign tidak valid",
+		})
+		return assign, user, false
+	}
+
+	err = h.DB.First(&assign, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Task assign tidak ditemukan",
+		})
+		return assign, user, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil task assign",
+			"error":   err.Error(),
+		})
+		return assign, user, false
+	}
+
+	// guard pemilik penugasan, atau koordinator di branch task
+	branchID, err := tasks.AssignBranchID(h.DB, id)
+	if err != nil || (assign.UserTadID != uint(user.ID) && !(user.IsSupervisor() && user.CanAccessBranch(branchID))) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"message": "Tidak memiliki akses ke task ini",
+		})
+		return assign, user, false
+	}
+
+	return assign, user, true
+}
+
+// respondTransitionError response untuk error dari tasks.Transition
+func respondTransitionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, tasks.ErrAssignNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	case errors.Is(err, tasks.ErrTransitionDenied), errors.Is(err, tasks.ErrStatusChanged):
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	case errors.Is(err, tasks.ErrInvalidStatus):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	case errors.Is(err, tasks.ErrChecklistIncomplete):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengubah status task",
+			"error":   err.Error(),
+		})
+	}
+}
+
+// createTaskAssigns menyimpan penugasan baru beserta riwayat status awalnya
+func createTaskAssigns(tx *gorm.DB, assigns []models.TaskAssign, actorID int) error {
+	if err := tx.Create(&assigns).Error; err != nil {
+		return err
+	}
+
+	for _, assign := range assigns {
+		if err := tasks.RecordHistory(tx, assign.ID, "", assign.Status, actorID, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateGuards memastikan semua guard terdaftar di branch task
+func (h *TaskHandler) validateGuards(userIDs []uint, branchID int) error {
+	branches, err := tasks.GuardBranches(h.DB, userIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range userIDs {
+		guardBranch, ok := branches[id]
+		if !ok {
+			return fmt.Errorf("guard %d tidak ditemukan", id)
+		}
+		if guardBranch != branchID {
+			return fmt.Errorf("guard %d bukan anggota branch task", id)
+		}
+	}
+
+	return nil
+}
+
+func newTaskAssigns(taskID uint, userIDs []uint, start, end time.Time, note string, assignedBy int) []models.TaskAssign {
+	var notePtr *string
+	if note != "" {
+		notePtr = &note
+	}
+
+	assigns := make([]models.TaskAssign, 0, len(userIDs))
+	for _, userID := range userIDs {
+		assigns = append(assigns, models.TaskAssign{
+			TaskID:     taskID,
+			UserTadID:  userID,
+			Status:     models.TaskStatusAssigned,
+			Note:       notePtr,
+			StartTime:  &start,
+			EndTime:    &end,
+			AssignedBy: uint(assignedBy),
+		})
+	}
+	return assigns
+}
+
+// notifyAssigned mengabari guard yang mendapat penugasan baru
+func (h *TaskHandler) notifyAssigned(task models.Task, assigns []models.TaskAssign) {
+	for _, assign := range assigns {
+		h.notifyUsers([]uint{assign.UserTadID}, "task_assigned",
+			"Tugas baru", "Anda mendapat tugas "+task.Name, assign.ID)
+	}
+}
+
+func (h *TaskHandler) notifyUsers(userIDs []uint, notifType, title, body string, assignID uint) {
+	recipients := make([]int, 0, len(userIDs))
+	for _, id := range userIDs {
+		recipients = append(recipients, int(id))
+	}
+
+	if err := notification.Send(h.DB, recipients, notifType, title, body,
+		models.JSONMap{"task_assign_id": assignID}); err != nil {
+		log.Printf("⚠️ Gagal mengirim notifikasi task %d: %v", assignID, err)
+	}
+}
@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"api_patroliku_docker/middleware"
+	"api_patroliku_docker/models"
+	"api_patroliku_docker/tasks"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// VerifyTask - POST /api/v1/tasks/assign/:id/verify (koordinator)
+func (h *TaskHandler) VerifyTask(c *gin.Context) {
+	h.reviewTask(c, models.TaskStatusVerified)
+}
+
+// RejectTask - POST /api/v1/tasks/assign/:id/reject (koordinator)
+// Task kembali ke guard dengan alasan penolakan di review_note.
+func (h *TaskHandler) RejectTask(c *gin.Context) {
+	h.reviewTask(c, models.TaskStatusRejected)
+}
+
+func (h *TaskHandler) reviewTask(c *gin.Context, to string) {
+	assign, user, ok := h.loadTaskAssign(c)
+	if !ok {
+		return
+	}
+
+	if assign.UserTadID == uint(user.ID) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"message": "Tidak dapat mereview task sendiri",
+		})
+		return
+	}
+
+	var req models.TaskReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Data request tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	req.Note = strings.TrimSpace(req.Note)
+	if to == models.TaskStatusRejected && req.Note == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Alasan penolakan wajib diisi",
+		})
+		return
+	}
+
+	now := time.Now()
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := tasks.Transition(tx, assign.ID, to, user.ID, req.Note); err != nil {
+			return err
+		}
+
+		return tx.Model(&models.TaskAssign{}).
+			Where("id = ?", assign.ID).
+			Updates(map[string]interface{}{
+				"review_score": req.Score,
+				"review_note":  req.Note,
+				"reviewed_by":  user.ID,
+				"reviewed_at":  now,
+			}).Error
+	})
+	if err != nil {
+		respondTransitionError(c, err)
+		return
+	}
+
+	if to == models.TaskStatusVerified {
+		h.notifyUsers([]uint{assign.UserTadID}, "task_verified",
+			"Tugas diverifikasi", "Tugas Anda telah diverifikasi koordinator", assign.ID)
+	} else {
+		h.notifyUsers([]uint{assign.UserTadID}, "task_rejected",
+			"Tugas ditolak", "Tugas Anda perlu diperbaiki: "+req.Note, assign.ID)
+	}
+
+	message := "Task berhasil diverifikasi"
+	if to == models.TaskStatusRejected {
+		message = "Task ditolak dan dikembalikan ke guard"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": message,
+		"data": gin.H{
+			"id":           assign.ID,
+			"status":       to,
+			"review_score": req.Score,
+			"review_note":  req.Note,
+			"reviewed_by":  user.ID,
+			"reviewed_at":  now,
+		},
+	})
+}
+
+// GetTaskMetrics - GET /api/v1/tasks/metrics?start_date=2025-12-01&end_date=2025-12-31 (koordinator)
+// Rekap verifikasi per guard dan per branch. Admin bisa memilih branch_id, tanpa branch_id semua branch.
+func (h *TaskHandler) GetTaskMetrics(c *gin.Context) {
+	user, _ := middleware.CurrentUser(c)
+
+	start, end, ok := reportRange(c)
+	if !ok {
+		return
+	}
+
+	// ===== base query =====
+	query := h.DB.Table("task_assign ta").
+		Joins("JOIN task t ON t.id = ta.task_id AND t.deleted_at IS NULL").
+		Joins("LEFT JOIN users u ON u.id = ta.user_tad_id").
+		Joins("LEFT JOIN user_tad_information uti ON uti.user_id = ta.user_tad_id").
+		Joins("LEFT JOIN branch b ON b.id = COALESCE(NULLIF(t.branch_id, 0), uti.branch_id)").
+		Joins(`LEFT JOIN (
+			SELECT task_assign_id, COUNT(*) AS total
+			FROM task_assign_history
+			WHERE to_status = ?
+			GROUP BY task_assign_id
+		) rj ON rj.task_assign_id = ta.id`, models.TaskStatusRejected).
+		Where("ta.status <> ?", models.TaskStatusCancelled).
+		Where("COALESCE(ta.start_time, ta.created_at) >= ? AND COALESCE(ta.start_time, ta.created_at) < ?",
+			start, end.AddDate(0, 0, 1))
+
+	if !user.IsAdmin() {
+		query = query.Where("b.id = ?", user.BranchID)
+	} else if branchID := c.Query("branch_id"); branchID != "" {
+		query = query.Where("b.id = ?", branchID)
+	}
+
+	if userTadID := c.Query("user_tad_id"); userTadID != "" {
+		query = query.Where("ta.user_tad_id = ?", userTadID)
+	}
+
+	metricColumns := `
+		COUNT(*) AS total,
+		COUNT(*) FILTER (WHERE ta.status IN ('submitted', 'verified')) AS submitted,
+		COUNT(*) FILTER (WHERE ta.status = 'verified') AS verified,
+		COUNT(*) FILTER (WHERE ta.status = 'verified' AND rj.total IS NULL) AS first_pass,
+		COUNT(*) FILTER (WHERE ta.status = 'submitted') AS waiting_review,
+		COUNT(*) FILTER (WHERE rj.total > 0) AS rejected_tasks,
+		COALESCE(SUM(rj.total), 0) AS rejections,
+		AVG(ta.review_score) AS avg_score
+	`
+
+	// ===== per guard =====
+	var guards []taskMetricRow
+	if err := query.Session(&gorm.Session{}).
+		Select("ta.user_tad_id, u.name AS user_name, b.id AS branch_id, " + metricColumns).
+		Group("ta.user_tad_id, u.name, b.id").
+		Order("u.name ASC").
+		Scan(&guards).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil metrik guard",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// ===== per branch =====
+	var branches []taskMetricRow
+	if err := query.Session(&gorm.Session{}).
+		Select("b.id AS branch_id, b.name AS branch_name, " + metricColumns).
+		Group("b.id, b.name").
+		Order("b.name ASC").
+		Scan(&branches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil metrik branch",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	for i := range guards {
+		guards[i].computeRates()
+	}
+	for i := range branches {
+		branches[i].computeRates()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Metrik verifikasi task berhasil diambil",
+		"data": gin.H{
+			"start_date": start.Format("2006-01-02"),
+			"end_date":   end.Format("2006-01-02"),
+			"guards":     guards,
+			"branches":   branches,
+		},
+	})
+}
+
+// taskMetricRow satu baris rekap verifikasi (per guard atau per branch)
+type taskMetricRow struct {
+	UserTadID     *int     `json:"user_tad_id,omitempty"`
+	UserName      *string  `json:"user_name,omitempty"`
+	BranchID      *int     `json:"branch_id"`
+	BranchName    *string  `json:"branch_name,omitempty"`
+	Total         int      `json:"total"`
+	Submitted     int      `json:"submitted"`
+	Verified      int      `json:"verified"`
+	FirstPass     int      `json:"first_pass"`
+	WaitingReview int      `json:"waiting_review"`
+	RejectedTasks int      `json:"rejected_tasks"`
+	Rejections    int      `json:"rejections"`
+	AvgScore      *float64 `json:"avg_score"`
+
+	CompletionRate   float64 `json:"completion_rate" gorm:"-"`   // % task yang disubmit
+	VerificationRate float64 `json:"verification_rate" gorm:"-"` // % task yang diverifikasi
+	FirstPassRate    float64 `json:"first_pass_rate" gorm:"-"`   // % verifikasi tanpa pernah ditolak
+}
+
+func (m *taskMetricRow) computeRates() {
+	if m.Total > 0 {
+		m.CompletionRate = percent(m.Submitted, m.Total)
+		m.VerificationRate = percent(m.Verified, m.Total)
+	}
+	if m.Verified > 0 {
+		m.FirstPassRate = percent(m.FirstPass, m.Verified)
+	}
+}
+
+func percent(part, total int) float64 {
+	return math.Round(float64(part)*10000/float64(total)) / 100
+}
+
+// reportRange rentang tanggal laporan dari start_date & end_date, default awal bulan ini sampai hari ini
+func reportRange(c *gin.Context) (time.Time, time.Time, bool) {
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+
+	if raw := c.Query("start_date"); raw != "" {
+		t, err := time.ParseInLocation("2006-01-02", raw, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "format start_date harus YYYY-MM-DD",
+			})
+			return start, end, false
+		}
+		start = t
+	}
+
+	if raw := c.Query("end_date"); raw != "" {
+		t, err := time.ParseInLocation("2006-01-02", raw, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "format end_date harus YYYY-MM-DD",
+			})
+			return start, end, false
+		}
+		end = t
+	}
+
+	if end.Before(start) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "end_date tidak boleh sebelum start_date",
+		})
+		return start, end, false
+	}
+
+	if end.Sub(start) > 366*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Rentang tanggal maksimal 366 hari",
+		})
+		return start, end, false
+	}
+
+	return start, end, true
+}
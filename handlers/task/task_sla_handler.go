@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"api_patroliku_docker/middleware"
+	"api_patroliku_docker/models"
+	"api_patroliku_docker/notification"
+	"api_patroliku_docker/tasks"
+
+	"github.com/gin-gonic/gin"
+)
+
+// slaWorkerInterval jeda antar pengecekan penugasan overdue
+const slaWorkerInterval = time.Minute
+
+// overdueEscalationDelay jeda setelah end_time sebelum koordinator dikabari
+func overdueEscalationDelay() time.Duration {
+	if minutes, err := strconv.Atoi(os.Getenv("TASK_OVERDUE_ESCALATION_MINUTES")); err == nil && minutes >= 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+	return 30 * time.Minute
+}
+
+// StartSLAWorker menandai penugasan overdue dan mengeskalasinya di background sampai ctx dibatalkan
+func (h *TaskHandler) StartSLAWorker(ctx context.Context) {
+	if h.DB == nil {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(slaWorkerInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				h.markOverdue()
+				h.escalateOverdue()
+			}
+		}
+	}()
+}
+
+// markOverdue level 1: guard dikabari saat penugasannya melewati end_time
+func (h *TaskHandler) markOverdue() {
+	marked, err := tasks.MarkOverdue(h.DB, time.Now())
+	if err != nil {
+		log.Printf("⚠️ Gagal mengambil task assign overdue: %v", err)
+		return
+	}
+
+	for _, assign := range marked {
+		h.notifyUsers([]uint{assign.UserTadID}, "task_overdue",
+			"Tugas terlambat", "Batas waktu tugas Anda sudah lewat, segera selesaikan", assign.ID)
+	}
+}
+
+// escalateOverdue level 2: koordinator branch dikabari jika penugasan masih overdue setelah jeda eskalasi
+func (h *TaskHandler) escalateOverdue() {
+	deadline := time.Now().Add(-overdueEscalationDelay())
+
+	var overdue []models.TaskAssign
+	if err := h.DB.Where("status = ? AND overdue_escalated_at IS NULL AND end_time <= ?",
+		models.TaskStatusOverdue, deadline).
+		Find(&overdue).Error; err != nil {
+		log.Printf("⚠️ Gagal mengambil task assign untuk eskalasi: %v", err)
+		return
+	}
+
+	for _, assign := range overdue {
+		// klaim eskalasi supaya tidak dobel jika ada lebih dari satu instance
+		result := h.DB.Model(&models.TaskAssign{}).
+			Where("id = ? AND status = ? AND overdue_escalated_at IS NULL", assign.ID, models.TaskStatusOverdue).
+			Update("overdue_escalated_at", time.Now())
+		if result.Error != nil {
+			log.Printf("⚠️ Gagal eskalasi task assign %d: %v", assign.ID, result.Error)
+			continue
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		branchID, err := tasks.AssignBranchID(h.DB, int(assign.ID))
+		if err != nil {
+			log.Printf("⚠️ Gagal mengambil branch task assign %d: %v", assign.ID, err)
+			continue
+		}
+
+		recipients, err := notification.BranchUsersByType(h.DB, branchID, middleware.CoordinatorUserTypes)
+		if err != nil {
+			log.Printf("⚠️ Gagal mengambil koordinator branch %d: %v", branchID, err)
+			continue
+		}
+
+		var info struct {
+			TaskName  string
+			GuardName string
+		}
+		h.DB.Raw(`
+			SELECT t.name AS task_name, u.name AS guard_name
+			FROM task_assign ta
+			LEFT JOIN task t ON t.id = ta.task_id
+			LEFT JOIN users u ON u.id = ta.user_tad_id
+			WHERE ta.id = ?
+		`, assign.ID).Scan(&info)
+
+		if err := notification.Send(h.DB, recipients, "task_overdue_escalated",
+			"Tugas belum diselesaikan",
+			fmt.Sprintf("%s belum menyelesaikan tugas %s yang sudah melewati batas waktu", info.GuardName, info.TaskName),
+			models.JSONMap{"task_assign_id": assign.ID}); err != nil {
+			log.Printf("⚠️ Gagal mengirim eskalasi task assign %d: %v", assign.ID, err)
+		}
+	}
+}
+
+// GetTaskSLAReport - GET /api/v1/tasks/sla-report?start_date=2025-12-01&end_date=2025-12-31 (koordinator)
+// Kepatuhan SLA per branch dan tipe task untuk penugasan dengan start_time di rentang tanggal.
+func (h *TaskHandler) GetTaskSLAReport(c *gin.Context) {
+	user, _ := middleware.CurrentUser(c)
+
+	start, end, ok := reportRange(c)
+	if !ok {
+		return
+	}
+
+	query := h.DB.Table("task_assign ta").
+		Joins("JOIN task t ON t.id = ta.task_id AND t.deleted_at IS NULL").
+		Joins("LEFT JOIN task_type tt ON tt.id = t.task_type_id").
+		Joins("LEFT JOIN user_tad_information uti ON uti.user_id = ta.user_tad_id").
+		Joins("LEFT JOIN branch b ON b.id = COALESCE(NULLIF(t.branch_id, 0), uti.branch_id)").
+		Joins(tasks.HistoryTimesJoin).
+		Where("ta.status <> ?", models.TaskStatusCancelled).
+		Where("ta.end_time IS NOT NULL").
+		Where("ta.start_time >= ? AND ta.start_time < ?", start, end.AddDate(0, 0, 1))
+
+	if !user.IsAdmin() {
+		query = query.Where("b.id = ?", user.BranchID)
+	} else if branchID := c.Query("branch_id"); branchID != "" {
+		query = query.Where("b.id = ?", branchID)
+	}
+
+	if taskTypeID := c.Query("task_type_id"); taskTypeID != "" {
+		query = query.Where("t.task_type_id = ?", taskTypeID)
+	}
+
+	type SLAReportRow struct {
+		BranchID                 *int     `json:"branch_id"`
+		BranchName               *string  `json:"branch_name"`
+		TaskTypeID               *int     `json:"task_type_id"`
+		TaskType                 *string  `json:"task_type"`
+		Total                    int      `json:"total"`
+		OnTime                   int      `json:"on_time"`
+		Late                     int      `json:"late"`
+		Missed                   int      `json:"missed"`  // lewat end_time dan belum disubmit
+		Pending                  int      `json:"pending"` // belum disubmit, masih dalam jendela waktu
+		AvgTimeToStartMinutes    *float64 `json:"avg_time_to_start_minutes"`
+		AvgTimeToCompleteMinutes *float64 `json:"avg_time_to_complete_minutes"`
+		ComplianceRate           float64  `json:"compliance_rate" gorm:"-"` // % tepat waktu dari penugasan yang sudah jatuh tempo
+	}
+
+	var rows []SLAReportRow
+	if err := query.
+		Select(`
+			b.id AS branch_id,
+			b.name AS branch_name,
+			tt.id AS task_type_id,
+			tt.name AS task_type,
+			COUNT(*) AS total,
+			COUNT(*) FILTER (WHERE sla.submitted_at <= ta.end_time) AS on_time,
+			COUNT(*) FILTER (WHERE sla.submitted_at > ta.end_time) AS late,
+			COUNT(*) FILTER (WHERE sla.submitted_at IS NULL AND ta.end_time < NOW()) AS missed,
+			COUNT(*) FILTER (WHERE sla.submitted_at IS NULL AND ta.end_time >= NOW()) AS pending,
+			ROUND(AVG(EXTRACT(EPOCH FROM (sla.started_at - ta.start_time)) / 60)::numeric, 1) AS avg_time_to_start_minutes,
+			ROUND(AVG(EXTRACT(EPOCH FROM (sla.submitted_at - sla.started_at)) / 60)::numeric, 1) AS avg_time_to_complete_minutes
+		`).
+		Group("b.id, b.name, tt.id, tt.name").
+		Order("b.name ASC, tt.name ASC").
+		Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil laporan SLA",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	var summary SLAReportRow
+	for i := range rows {
+		if due := rows[i].OnTime + rows[i].Late + rows[i].Missed; due > 0 {
+			rows[i].ComplianceRate = percent(rows[i].OnTime, due)
+		}
+		summary.Total += rows[i].Total
+		summary.OnTime += rows[i].OnTime
+		summary.Late += rows[i].Late
+		summary.Missed += rows[i].Missed
+		summary.Pending += rows[i].Pending
+	}
+	if due := summary.OnTime + summary.Late + summary.Missed; due > 0 {
+		summary.ComplianceRate = percent(summary.OnTime, due)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Laporan SLA task berhasil diambil",
+		"data": gin.H{
+			"start_date": start.Format("2006-01-02"),
+			"end_date":   end.Format("2006-01-02"),
+			"rows":       rows,
+			"summary": gin.H{
+				"total":           summary.Total,
+				"on_time":         summary.OnTime,
+				"late":            summary.Late,
+				"missed":          summary.Missed,
+				"pending":         summary.Pending,
+				"compliance_rate": summary.ComplianceRate,
+			},
+		},
+	})
+}
+
+// taskSLA SLA penugasan untuk response detail, kosong jika gagal dihitung
+func (h *TaskHandler) taskSLA(assignID uint) *tasks.SLA {
+	sla, err := tasks.AssignSLA(h.DB, assignID)
+	if err != nil {
+		log.Printf("⚠️ Gagal menghitung SLA task assign %d: %v", assignID, err)
+		return nil
+	}
+	return &sla
+}
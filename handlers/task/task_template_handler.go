@@ -0,0 +1,367 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"api_patroliku_docker/middleware"
+	"api_patroliku_docker/models"
+	"api_patroliku_docker/tasks"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// templateWorkerInterval jeda antar pengecekan template task rutin
+	templateWorkerInterval = 5 * time.Minute
+	// templateLookahead penugasan dibuat sejak kemunculan ini sudah dekat
+	templateLookahead = time.Hour
+)
+
+// GetTaskTemplates - GET /api/v1/tasks/templates (koordinator)
+func (h *TaskHandler) GetTaskTemplates(c *gin.Context) {
+	user, _ := middleware.CurrentUser(c)
+
+	query := h.DB.Table("task_template tpl").
+		Joins("LEFT JOIN task t ON t.id = tpl.task_id").
+		Joins("LEFT JOIN schedule_shift ss ON ss.id = tpl.shift_id").
+		Where("tpl.deleted_at IS NULL")
+
+	if !user.IsAdmin() {
+		query = query.Where("tpl.branch_id = ?", user.BranchID)
+	} else if branchID := c.Query("branch_id"); branchID != "" {
+		query = query.Where("tpl.branch_id = ?", branchID)
+	}
+
+	if active := c.Query("active"); active != "" {
+		query = query.Where("tpl.active = ?", active == "true")
+	}
+
+	type TemplateResponse struct {
+		models.TaskTemplate
+		TaskName  *string `json:"task_name"`
+		ShiftName *string `json:"shift_name"`
+	}
+
+	var data []TemplateResponse
+	if err := query.
+		Select("tpl.*, t.name AS task_name, ss.name AS shift_name").
+		Order("tpl.id DESC").
+		Scan(&data).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil template task",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Template task berhasil diambil",
+		"data":    data,
+	})
+}
+
+// CreateTaskTemplate - POST /api/v1/tasks/templates (koordinator)
+func (h *TaskHandler) CreateTaskTemplate(c *gin.Context) {
+	user, _ := middleware.CurrentUser(c)
+
+	var req models.TaskTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Data request tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	tpl := models.TaskTemplate{
+		Active:    true,
+		CreatedBy: uint(user.ID),
+	}
+	if !h.applyTemplateRequest(c, user, &tpl, req) {
+		return
+	}
+
+	// Active diisi eksplisit supaya false tidak diganti default kolom
+	if err := h.DB.Select("*").Omit("id", "deleted_at").Create(&tpl).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal menyimpan template task",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "Template task berhasil dibuat",
+		"data":    tpl,
+	})
+}
+
+// UpdateTaskTemplate - PUT /api/v1/tasks/templates/:id (koordinator)
+func (h *TaskHandler) UpdateTaskTemplate(c *gin.Context) {
+	tpl, user, ok := h.loadTaskTemplate(c)
+	if !ok {
+		return
+	}
+
+	var req models.TaskTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Data request tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if !h.applyTemplateRequest(c, user, &tpl, req) {
+		return
+	}
+
+	if err := h.DB.Model(&tpl).
+		Select("*").
+		Omit("id", "created_by", "created_at", "deleted_at").
+		Updates(&tpl).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengubah template task",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Template task berhasil diubah",
+		"data":    tpl,
+	})
+}
+
+// DeleteTaskTemplate - DELETE /api/v1/tasks/templates/:id (koordinator)
+// Penugasan yang sudah dibuat dari template tetap ada.
+func (h *TaskHandler) DeleteTaskTemplate(c *gin.Context) {
+	tpl, _, ok := h.loadTaskTemplate(c)
+	if !ok {
+		return
+	}
+
+	if err := h.DB.Model(&models.TaskTemplate{}).
+		Where("id = ?", tpl.ID).
+		Updates(map[string]interface{}{
+			"active":     false,
+			"deleted_at": time.Now(),
+		}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal menghapus template task",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Template task berhasil dihapus",
+	})
+}
+
+// loadTaskTemplate mengambil template dari path :id di branch yang bisa diakses user
+func (h *TaskHandler) loadTaskTemplate(c *gin.Context) (models.TaskTemplate, middleware.AuthUser, bool) {
+	var tpl models.TaskTemplate
+	user, _ := middleware.CurrentUser(c)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "ID template tidak valid",
+		})
+		return tpl, user, false
+	}
+
+	err = h.DB.Where("id = ? AND deleted_at IS NULL", id).First(&tpl).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":  "error",
+			"message": "Template task tidak ditemukan",
+		})
+		return tpl, user, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil template task",
+			"error":   err.Error(),
+		})
+		return tpl, user, false
+	}
+
+	if !user.CanAccessBranch(int(tpl.BranchID)) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"message": "Tidak memiliki akses ke template ini",
+		})
+		return tpl, user, false
+	}
+
+	return tpl, user, true
+}
+
+// applyTemplateRequest validasi request lalu mengisi field template. Mengirim response error jika gagal.
+func (h *TaskHandler) applyTemplateRequest(c *gin.Context, user middleware.AuthUser, tpl *models.TaskTemplate, req models.TaskTemplateRequest) bool {
+	// ===== task master =====
+	var task models.Task
+	err := h.DB.Where("id = ? AND deleted_at IS NULL", req.TaskID).First(&task).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "task_id tidak ditemukan",
+		})
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil task",
+			"error":   err.Error(),
+		})
+		return false
+	}
+
+	// task lama tanpa branch mengikuti branch koordinator
+	branchID := int(task.BranchID)
+	if branchID == 0 {
+		branchID = user.BranchID
+	}
+	if !user.CanAccessBranch(branchID) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":  "error",
+			"message": "Tidak memiliki akses ke task ini",
+		})
+		return false
+	}
+
+	// ===== shift =====
+	if req.ShiftID != nil {
+		var shiftCount int64
+		if err := h.DB.Raw(`
+			SELECT COUNT(*) FROM schedule_shift
+			WHERE id = ? AND deleted_at IS NULL AND (branch_id IS NULL OR branch_id = ?)
+		`, *req.ShiftID, branchID).Scan(&shiftCount).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"message": "Gagal mengambil shift",
+				"error":   err.Error(),
+			})
+			return false
+		}
+		if shiftCount == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "shift_id tidak ditemukan di branch task",
+			})
+			return false
+		}
+	}
+
+	// ===== recurrence =====
+	weekdays := make([]string, 0, len(req.Weekdays))
+	for _, day := range req.Weekdays {
+		weekdays = append(weekdays, strconv.Itoa(day))
+	}
+
+	tpl.TaskID = task.ID
+	tpl.BranchID = uint(branchID)
+	tpl.ShiftID = req.ShiftID
+	tpl.Recurrence = req.Recurrence
+	tpl.Weekdays = ""
+	tpl.TimeOfDay = ""
+	tpl.CronExpr = ""
+	if req.Recurrence == models.TaskRecurrenceWeekly {
+		tpl.Weekdays = strings.Join(weekdays, ",")
+		tpl.TimeOfDay = req.TimeOfDay
+	} else {
+		tpl.CronExpr = strings.TrimSpace(req.CronExpr)
+	}
+	tpl.DurationMinutes = req.DurationMinutes
+	tpl.Note = req.Note
+	if req.Active != nil {
+		tpl.Active = *req.Active
+	}
+
+	if _, err := tasks.NewRecurrence(*tpl); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return false
+	}
+
+	return true
+}
+
+// StartTemplateWorker membuat penugasan dari template task rutin di background sampai ctx dibatalkan
+func (h *TaskHandler) StartTemplateWorker(ctx context.Context) {
+	if h.DB == nil {
+		return
+	}
+
+	go func() {
+		h.generateTemplateAssigns()
+
+		ticker := time.NewTicker(templateWorkerInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				h.generateTemplateAssigns()
+			}
+		}
+	}()
+}
+
+// generateTemplateAssigns memproses kemunculan template yang jendelanya masih berjalan
+// sampai templateLookahead ke depan. Kemunculan yang sudah dibuat dilewati.
+func (h *TaskHandler) generateTemplateAssigns() {
+	var templates []models.TaskTemplate
+	if err := h.DB.Table("task_template tpl").
+		Select("tpl.*").
+		Joins("JOIN task t ON t.id = tpl.task_id AND t.deleted_at IS NULL").
+		Where("tpl.active = ? AND tpl.deleted_at IS NULL", true).
+		Scan(&templates).Error; err != nil {
+		log.Printf("⚠️ Gagal mengambil template task: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for _, tpl := range templates {
+		// kemunculan yang belum lewat batas waktunya tetap dibuat, misal setelah server restart
+		from := now.Add(-time.Duration(tpl.DurationMinutes) * time.Minute)
+
+		created, err := tasks.GenerateFromTemplate(h.DB, tpl, from, now.Add(templateLookahead))
+		if err != nil {
+			log.Printf("⚠️ Gagal membuat penugasan dari template %d: %v", tpl.ID, err)
+		}
+		if len(created) == 0 {
+			continue
+		}
+
+		var task models.Task
+		h.DB.First(&task, tpl.TaskID)
+		h.notifyAssigned(task, created)
+	}
+}
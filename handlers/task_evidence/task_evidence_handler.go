@@ -1,15 +1,21 @@
 package handlers
 
 import (
-	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"mime/multipart"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"api_patroliku_docker/database"
+	"api_patroliku_docker/events"
+	"api_patroliku_docker/middleware"
+	"api_patroliku_docker/models"
+	"api_patroliku_docker/storage"
+	"api_patroliku_docker/tasks"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -25,19 +31,31 @@ func NewTaskEvidenceHandler() *TaskEvidenceHandler {
 	}
 }
 
-// UploadTaskEvidence - Upload before dan after photo dalam satu function
+// UploadTaskEvidence - POST /api/v1/task-evidence/upload (multipart)
+// Field: task_assign_id, phase (before/after), photos[] (bisa lebih dari satu),
+// captions[] (urutan sama dengan photos[]), note, status.
+// Tanpa phase, fase diambil dari field lama before_photo / after_photo.
+// Uploader adalah user login dan harus guard yang ditugaskan; user_tad_id lama hanya dicocokkan.
 func (h *TaskEvidenceHandler) UploadTaskEvidence(c *gin.Context) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   true,
+			"message": "Unauthorized",
+		})
+		return
+	}
+	userTadID := user.ID
+
 	// ===== Parse form data =====
 	taskAssignIDStr := c.PostForm("task_assign_id")
-	userTadIDStr := c.PostForm("user_tad_id")
-	note := c.PostForm("note")
-	taskCondition := c.PostForm("task_condition") // "1" = before, "2" = after
-	status := c.PostForm("status")                // Status task evidence
+	note := strings.TrimSpace(c.PostForm("note"))
+	status := c.PostForm("status") // Status task evidence, hanya diubah jika diisi
 
-	if taskAssignIDStr == "" || userTadIDStr == "" {
+	if taskAssignIDStr == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   true,
-			"message": "task_assign_id dan user_tad_id wajib diisi",
+			"message": "task_assign_id wajib diisi",
 		})
 		return
 	}
@@ -51,10 +69,31 @@ func (h *TaskEvidenceHandler) UploadTaskEvidence(c *gin.Context) {
 		return
 	}
 
-	userTadID, err := strconv.Atoi(userTadIDStr)
+	if userTadIDStr := c.PostForm("user_tad_id"); userTadIDStr != "" {
+		if formUserID, err := strconv.Atoi(userTadIDStr); err != nil || formUserID != userTadID {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   true,
+				"message": "user_tad_id tidak sesuai dengan user login",
+			})
+			return
+		}
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": "Request harus multipart/form-data",
+		})
+		return
+	}
+
+	// ===== Fase evidence =====
+	phase, err := evidencePhase(c.PostForm("phase"), form)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": true,
+			"error":   true,
+			"message": err.Error(),
 		})
 		return
 	}
@@ -75,182 +114,308 @@ func (h *TaskEvidenceHandler) UploadTaskEvidence(c *gin.Context) {
 		return
 	}
 
-	// ===== Handle file upload (bisa null) =====
-	var photoURLs []string
-	var evidenceType string // "before" atau "after"
+	// ===== Evidence hanya oleh guard yang ditugaskan, untuk task yang masih dikerjakan =====
+	var assign struct {
+		Status    string
+		UserTadID int
+	}
+	if err := h.DB.Raw(`SELECT status, user_tad_id FROM task_assign WHERE id = ?`, taskAssignID).
+		Scan(&assign).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":         true,
+			"message":       "Gagal mengambil task assign",
+			"error_details": err.Error(),
+		})
+		return
+	}
+	if assign.Status == "" {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   true,
+			"message": "Task assign tidak ditemukan",
+		})
+		return
+	}
+	if assign.UserTadID != userTadID {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   true,
+			"message": "Hanya guard yang ditugaskan yang dapat mengupload evidence",
+		})
+		return
+	}
+	if !tasks.IsOpen(assign.Status) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   true,
+			"message": "Task sudah " + assign.Status + ", evidence tidak bisa diubah",
+		})
+		return
+	}
+
+	// ===== File & caption =====
+	files := append(append(form.File["photos[]"], form.File["photos"]...), form.File[phase+"_photo"]...)
+	captions := append(form.Value["captions[]"], form.Value["captions"]...)
 
-	beforeFile, beforeErr := c.FormFile("before_photo")
-	afterFile, afterErr := c.FormFile("after_photo")
+	if len(files) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": "Minimal satu foto harus diupload",
+		})
+		return
+	}
 
-	if beforeErr == nil && beforeFile != nil {
-		// Upload before photo
-		evidenceType = "before"
-		photoURL, err := h.saveUploadedFile(c, beforeFile, "before")
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
+	if len(files) > maxEvidencePhotos {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": fmt.Sprintf("Maksimal %d foto per upload", maxEvidencePhotos),
+		})
+		return
+	}
+
+	for _, file := range files {
+		if !storage.IsImage(file) {
+			c.JSON(http.StatusBadRequest, gin.H{
 				"error":   true,
-				"message": "Gagal menyimpan before photo",
+				"message": "File " + file.Filename + " harus berupa gambar",
 			})
 			return
 		}
-		photoURLs = append(photoURLs, photoURL)
 	}
 
-	if afterErr == nil && afterFile != nil {
-		// Upload after photo
-		evidenceType = "after"
-		photoURL, err := h.saveUploadedFile(c, afterFile, "after")
+	// after photo menyelesaikan task, checklist wajib harus sudah lengkap
+	if phase == models.EvidencePhaseAfter {
+		if err := tasks.CheckChecklist(h.DB, uint(taskAssignID)); err != nil {
+			code := http.StatusInternalServerError
+			if errors.Is(err, tasks.ErrChecklistIncomplete) {
+				code = http.StatusUnprocessableEntity
+			}
+			c.JSON(code, gin.H{
+				"error":   true,
+				"message": err.Error(),
+			})
+			return
+		}
+	}
+
+	// ===== Simpan file =====
+	photos := make([]models.TaskEvidencePhoto, 0, len(files))
+	for i, file := range files {
+		photoURL, err := storage.SaveUploadedFile(c, file, "task_evidence/"+phase)
 		if err != nil {
+			removeEvidenceFiles(photos)
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error":   true,
-				"message": "Gagal menyimpan after photo",
+				"message": "Gagal menyimpan foto " + phase,
 			})
 			return
 		}
-		photoURLs = append(photoURLs, photoURL)
-	}
 
-	// ===== Jika tidak ada file yang diupload =====
-	if len(photoURLs) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   true,
-			"message": "Minimal satu foto harus diupload (before_photo atau after_photo)",
+		caption := ""
+		if i < len(captions) {
+			caption = strings.TrimSpace(captions[i])
+		}
+
+		photos = append(photos, models.TaskEvidencePhoto{
+			TaskAssignID: uint(taskAssignID),
+			Phase:        phase,
+			URL:          photoURL,
+			Caption:      caption,
+			UploadedBy:   uint(userTadID),
 		})
-		return
 	}
 
-	// ===== Cek apakah sudah ada task_evidence untuk task_assign_id ini =====
-	type ExistingEvidence struct {
-		ID           int
-		BeforePhotos string
-		AfterPhotos  string
+	photoURLs := make([]string, 0, len(photos))
+	for _, photo := range photos {
+		photoURLs = append(photoURLs, photo.URL)
 	}
 
-	var existing ExistingEvidence
-
-	h.DB.Raw(`
-		SELECT id, before_photos, after_photos 
-		FROM task_evidence 
-		WHERE task_assign_id = ?
-		LIMIT 1
-	`, taskAssignID).Scan(&existing)
-
-	// ===== Convert photo URLs to JSON =====
-	photosJSON, _ := json.Marshal(photoURLs)
-
-	// ===== Insert atau Update ke database =====
-	if existing.ID == 0 {
-		// INSERT baru
-		query := `
-			INSERT INTO task_evidence 
-			(task_assign_id, user_tad_id, branch_id, task_condition, before_photos, after_photos, status, note, created_at, updated_at)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-		`
-
-		var beforePhotosJSON, afterPhotosJSON interface{}
-		if evidenceType == "before" {
-			beforePhotosJSON = photosJSON
-			afterPhotosJSON = nil
-		} else {
-			beforePhotosJSON = nil
-			afterPhotosJSON = photosJSON
+	// ===== Simpan evidence, foto, catatan & status dalam satu transaksi =====
+	column := phase + "_photos"
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		// kunci penugasan supaya upload bersamaan tidak saling menimpa daftar foto
+		var lockedStatus string
+		if err := tx.Raw(`SELECT status FROM task_assign WHERE id = ? FOR UPDATE`, taskAssignID).
+			Scan(&lockedStatus).Error; err != nil {
+			return err
 		}
-
-		if err := h.DB.Exec(
-			query,
-			taskAssignID,
-			userTadID,
-			branchID,
-			taskCondition,
-			beforePhotosJSON,
-			afterPhotosJSON,
-			status,
-			note,
-			time.Now(),
-			time.Now(),
-		).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":         true,
-				"message":       "Gagal menyimpan task evidence",
-				"error_details": err.Error(),
-			})
-			return
+		if !tasks.IsOpen(lockedStatus) {
+			return fmt.Errorf("%w: task sudah %s", tasks.ErrStatusChanged, lockedStatus)
 		}
-	} else {
-		// UPDATE existing
-		query := `
-			UPDATE task_evidence 
-			SET 
-		`
-
-		params := []interface{}{}
-
-		if evidenceType == "before" {
-			// Update before photos
-			var finalBeforePhotos []string
-
-			// Parse existing before photos
-			if existing.BeforePhotos != "" {
-				json.Unmarshal([]byte(existing.BeforePhotos), &finalBeforePhotos)
-			}
 
-			// Append new photos
-			finalBeforePhotos = append(finalBeforePhotos, photoURLs...)
-
-			// Convert to JSON
-			finalBeforeJSON, _ := json.Marshal(finalBeforePhotos)
+		var existing struct {
+			ID     int
+			Photos models.JSONStringList
+		}
+		if err := tx.Raw(`
+			SELECT id, `+column+` AS photos
+			FROM task_evidence 
+			WHERE task_assign_id = ?
+			ORDER BY id ASC
+			LIMIT 1
+		`, taskAssignID).Scan(&existing).Error; err != nil {
+			return err
+		}
 
-			query += " before_photos = ?, "
-			params = append(params, finalBeforeJSON)
+		now := time.Now()
+		if existing.ID == 0 {
+			var latestNote interface{}
+			if note != "" {
+				latestNote = note
+			}
 
-		} else if evidenceType == "after" {
-			// Update after photos
-			var finalAfterPhotos []string
+			if err := tx.Exec(`
+				INSERT INTO task_evidence 
+				(task_assign_id, user_tad_id, branch_id, task_condition, `+column+`, status, note, created_at, updated_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			`,
+				taskAssignID,
+				userTadID,
+				branchID,
+				evidenceCondition(phase),
+				models.JSONStringList(photoURLs),
+				status,
+				latestNote,
+				now,
+				now,
+			).Error; err != nil {
+				return err
+			}
+		} else {
+			// hanya field yang dikirim yang diubah, catatan lama tersimpan di task_evidence_note
+			query := `UPDATE task_evidence SET ` + column + ` = ?, task_condition = ?, updated_at = ?`
+			params := []interface{}{
+				append(existing.Photos, photoURLs...),
+				evidenceCondition(phase),
+				now,
+			}
 
-			// Parse existing after photos
-			if existing.AfterPhotos != "" {
-				json.Unmarshal([]byte(existing.AfterPhotos), &finalAfterPhotos)
+			if status != "" {
+				query += ", status = ?"
+				params = append(params, status)
+			}
+			if note != "" {
+				query += ", note = ?"
+				params = append(params, note)
 			}
 
-			// Append new photos
-			finalAfterPhotos = append(finalAfterPhotos, photoURLs...)
+			query += " WHERE id = ?"
+			params = append(params, existing.ID)
 
-			// Convert to JSON
-			finalAfterJSON, _ := json.Marshal(finalAfterPhotos)
+			if err := tx.Exec(query, params...).Error; err != nil {
+				return err
+			}
+		}
 
-			query += " after_photos = ?, "
-			params = append(params, finalAfterJSON)
+		if err := tx.Create(&photos).Error; err != nil {
+			return err
+		}
 
-			// Jika upload after photo, update status task_assign menjadi completed
-			h.updateTaskAssignStatus(taskAssignID, "completed")
+		if note != "" {
+			if err := tx.Create(&models.TaskEvidenceNote{
+				TaskAssignID: uint(taskAssignID),
+				Phase:        phase,
+				Note:         note,
+				UserID:       uint(userTadID),
+			}).Error; err != nil {
+				return err
+			}
 		}
 
-		query += " note = ?, updated_at = ? WHERE id = ?"
-		params = append(params, note, time.Now(), existing.ID)
+		// ===== Status: before photo → in_progress, after photo → submitted =====
+		return advanceTaskStatus(tx, taskAssignID, userTadID, phase)
+	})
 
-		if err := h.DB.Exec(query, params...).Error; err != nil {
+	if err != nil {
+		removeEvidenceFiles(photos)
+
+		switch {
+		case errors.Is(err, tasks.ErrChecklistIncomplete):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":   true,
+				"message": err.Error(),
+			})
+		case errors.Is(err, tasks.ErrTransitionDenied), errors.Is(err, tasks.ErrStatusChanged):
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   true,
+				"message": err.Error(),
+			})
+		default:
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error":         true,
-				"message":       "Gagal update task evidence",
+				"message":       "Gagal menyimpan task evidence",
 				"error_details": err.Error(),
 			})
-			return
 		}
+		return
 	}
 
+	events.Publish(events.Event{
+		Type:     events.TypeTaskEvidence,
+		BranchID: branchID,
+		UserID:   userTadID,
+		Data: gin.H{
+			"task_assign_id": taskAssignID,
+			"evidence_type":  phase,
+			"photo_urls":     photoURLs,
+		},
+	})
+
 	// ===== Response =====
 	c.JSON(http.StatusOK, gin.H{
 		"error":   false,
 		"message": "Task evidence berhasil disimpan",
 		"data": gin.H{
-			"evidence_type":  evidenceType,
+			"evidence_type":  phase,
 			"photo_urls":     photoURLs,
+			"photos":         photos,
 			"task_assign_id": taskAssignID,
 		},
 	})
 }
 
-// UpdateTaskAssign - Update status dan data task_assign
+// maxEvidencePhotos batas jumlah foto dalam satu upload
+const maxEvidencePhotos = 10
+
+// evidencePhase fase dari field phase, atau dari field foto lama jika phase kosong
+func evidencePhase(phase string, form *multipart.Form) (string, error) {
+	switch phase {
+	case models.EvidencePhaseBefore, models.EvidencePhaseAfter:
+		return phase, nil
+	case "":
+	default:
+		return "", fmt.Errorf("phase harus before atau after")
+	}
+
+	hasBefore := len(form.File["before_photo"]) > 0
+	hasAfter := len(form.File["after_photo"]) > 0
+
+	switch {
+	case hasBefore && hasAfter:
+		return "", fmt.Errorf("before_photo dan after_photo tidak bisa dikirim bersamaan, gunakan phase")
+	case hasBefore:
+		return models.EvidencePhaseBefore, nil
+	case hasAfter:
+		return models.EvidencePhaseAfter, nil
+	}
+	return "", fmt.Errorf("phase wajib diisi (before atau after)")
+}
+
+// evidenceCondition kode task_condition: "1" = before, "2" = after
+func evidenceCondition(phase string) string {
+	if phase == models.EvidencePhaseAfter {
+		return "2"
+	}
+	return "1"
+}
+
+// removeEvidenceFiles menghapus file yang sudah tersimpan jika penyimpanan evidence gagal
+func removeEvidenceFiles(photos []models.TaskEvidencePhoto) {
+	for _, photo := range photos {
+		if err := storage.RemovePublicFile(photo.URL); err != nil {
+			log.Printf("⚠️ Gagal menghapus file evidence %s: %v", photo.URL, err)
+		}
+	}
+}
+
+// UpdateTaskAssign - Update status dan data task_assign (verified / rejected lewat endpoint review)
 func (h *TaskEvidenceHandler) UpdateTaskAssign(c *gin.Context) {
 	taskAssignIDStr := c.Param("id")
 	if taskAssignIDStr == "" {
@@ -270,6 +435,17 @@ func (h *TaskEvidenceHandler) UpdateTaskAssign(c *gin.Context) {
 		return
 	}
 
+	// ===== Cek akses branch =====
+	user, _ := middleware.CurrentUser(c)
+	branchID, err := tasks.AssignBranchID(h.DB, taskAssignID)
+	if err != nil || !user.CanAccessBranch(branchID) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   true,
+			"message": "Tidak memiliki akses ke task ini",
+		})
+		return
+	}
+
 	// ===== Parse request body =====
 	var updateData struct {
 		Status    string  `json:"status"`
@@ -287,15 +463,19 @@ func (h *TaskEvidenceHandler) UpdateTaskAssign(c *gin.Context) {
 		return
 	}
 
+	// verifikasi & penolakan butuh skor, alasan dan pemeriksaan reviewer lewat endpoint review
+	if updateData.Status == models.TaskStatusVerified || updateData.Status == models.TaskStatusRejected {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": "Gunakan /tasks/assign/:id/verify atau /tasks/assign/:id/reject untuk mereview task",
+		})
+		return
+	}
+
 	// ===== Build update query dynamically =====
 	query := "UPDATE task_assign SET updated_at = ?"
 	params := []interface{}{time.Now()}
 
-	if updateData.Status != "" {
-		query += ", status = ?"
-		params = append(params, updateData.Status)
-	}
-
 	if updateData.Note != nil {
 		query += ", note = ?"
 		params = append(params, *updateData.Note)
@@ -319,18 +499,58 @@ func (h *TaskEvidenceHandler) UpdateTaskAssign(c *gin.Context) {
 	query += " WHERE id = ?"
 	params = append(params, taskAssignID)
 
-	// ===== Execute update =====
-	result := h.DB.Exec(query, params...)
-	if result.Error != nil {
+	// ===== Execute update (status lewat state machine) =====
+	var rowsAffected int64
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if updateData.Status != "" {
+			note := ""
+			if updateData.Note != nil {
+				note = *updateData.Note
+			}
+			if _, err := tasks.Transition(tx, uint(taskAssignID), updateData.Status, user.ID, note); err != nil {
+				return err
+			}
+		}
+
+		result := tx.Exec(query, params...)
+		rowsAffected = result.RowsAffected
+		return result.Error
+	})
+
+	if errors.Is(err, tasks.ErrTransitionDenied) || errors.Is(err, tasks.ErrStatusChanged) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if errors.Is(err, tasks.ErrInvalidStatus) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if errors.Is(err, tasks.ErrChecklistIncomplete) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err != nil && !errors.Is(err, tasks.ErrAssignNotFound) {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":         true,
 			"message":       "Gagal update task assign",
-			"error_details": result.Error.Error(),
+			"error_details": err.Error(),
 		})
 		return
 	}
 
-	if result.RowsAffected == 0 {
+	if err != nil || rowsAffected == 0 {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error":   true,
 			"message": "Task assign tidak ditemukan",
@@ -350,69 +570,51 @@ func (h *TaskEvidenceHandler) UpdateTaskAssign(c *gin.Context) {
 	})
 }
 
-// Helper function to save uploaded file
-func (h *TaskEvidenceHandler) saveUploadedFile(c *gin.Context, fileHeader *multipart.FileHeader, evidenceType string) (string, error) {
-	// Create upload directory
-	uploadDir := "uploads/task_evidence/" + evidenceType
-	if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
-		return "", err
+// advanceTaskStatus memajukan status task_assign setelah upload evidence, di dalam transaksi pemanggil
+func advanceTaskStatus(tx *gorm.DB, taskAssignID, actorID int, phase string) error {
+	target := models.TaskStatusInProgress
+	if phase == models.EvidencePhaseAfter {
+		target = models.TaskStatusSubmitted
 	}
 
-	// Generate unique filename
-	filename := strconv.FormatInt(time.Now().UnixNano(), 10) + filepath.Ext(fileHeader.Filename)
-	filePath := filepath.Join(uploadDir, filename)
-
-	// Save file
-	if err := c.SaveUploadedFile(fileHeader, filePath); err != nil {
-		return "", err
+	var status string
+	if err := tx.Raw(`SELECT status FROM task_assign WHERE id = ?`, taskAssignID).
+		Scan(&status).Error; err != nil {
+		return err
 	}
 
-	// Generate full URL
-	scheme := "http"
-	if c.Request.TLS != nil {
-		scheme = "https"
+	if status == target {
+		return nil
 	}
 
-	photoURL := scheme + "://" + c.Request.Host + "/" + filePath
-	return photoURL, nil
-}
+	// after photo langsung dari assigned: mulai dulu baru submit
+	if !tasks.CanTransition(status, target) && tasks.CanTransition(status, models.TaskStatusInProgress) {
+		if _, err := tasks.Transition(tx, uint(taskAssignID), models.TaskStatusInProgress, actorID, ""); err != nil {
+			return err
+		}
+	}
 
-// Helper function to update task_assign status
-func (h *TaskEvidenceHandler) updateTaskAssignStatus(taskAssignID int, status string) error {
-	query := "UPDATE task_assign SET status = ?, updated_at = ? WHERE id = ?"
-	return h.DB.Exec(query, status, time.Now(), taskAssignID).Error
+	_, err := tasks.Transition(tx, uint(taskAssignID), target, actorID, "")
+	return err
 }
 
-// Helper function to get task evidence by task_assign_id
+// GetTaskEvidence - GET /api/v1/task-evidence/:id (id = task_assign_id)
 func (h *TaskEvidenceHandler) GetTaskEvidence(c *gin.Context) {
-	taskAssignIDStr := c.Param("id")
-	if taskAssignIDStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   true,
-			"message": "ID task assign wajib diisi",
-		})
-		return
-	}
-
-	taskAssignID, err := strconv.Atoi(taskAssignIDStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   true,
-			"message": "ID task assign tidak valid",
-		})
+	assign, ok := h.loadEvidenceAssign(c)
+	if !ok {
 		return
 	}
 
 	type TaskEvidenceResponse struct {
-		ID            int      `json:"id"`
-		TaskAssignID  int      `json:"task_assign_id"`
-		BeforePhotos  []string `json:"before_photos"`
-		AfterPhotos   []string `json:"after_photos"`
-		Status        string   `json:"status"`
-		Note          string   `json:"note"`
-		TaskCondition string   `json:"task_condition"`
-		CreatedAt     string   `json:"created_at"`
-		UpdatedAt     string   `json:"updated_at"`
+		ID            int                   `json:"id"`
+		TaskAssignID  int                   `json:"task_assign_id"`
+		BeforePhotos  models.JSONStringList `json:"before_photos"`
+		AfterPhotos   models.JSONStringList `json:"after_photos"`
+		Status        *string               `json:"status"`
+		Note          *string               `json:"note"`
+		TaskCondition *string               `json:"task_condition"`
+		CreatedAt     *time.Time            `json:"created_at"`
+		UpdatedAt     *time.Time            `json:"updated_at"`
 	}
 
 	var evidence TaskEvidenceResponse
@@ -433,10 +635,11 @@ func (h *TaskEvidenceHandler) GetTaskEvidence(c *gin.Context) {
 		LIMIT 1
 	`
 
-	if err := h.DB.Raw(query, taskAssignID).Scan(&evidence).Error; err != nil {
+	if err := h.DB.Raw(query, assign.ID).Scan(&evidence).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   true,
-			"message": "Gagal mengambil task evidence",
+			"error":         true,
+			"message":       "Gagal mengambil task evidence",
+			"error_details": err.Error(),
 		})
 		return
 	}
@@ -449,11 +652,204 @@ func (h *TaskEvidenceHandler) GetTaskEvidence(c *gin.Context) {
 		return
 	}
 
-	// Parse JSON strings to arrays
+	// array kosong lebih mudah diolah client daripada null
+	if evidence.BeforePhotos == nil {
+		evidence.BeforePhotos = models.JSONStringList{}
+	}
+	if evidence.AfterPhotos == nil {
+		evidence.AfterPhotos = models.JSONStringList{}
+	}
+
+	// ===== Keterangan foto & riwayat catatan =====
+	var photos []models.TaskEvidencePhoto
+	if err := h.DB.Where("task_assign_id = ?", assign.ID).
+		Order("id ASC").
+		Find(&photos).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":         true,
+			"message":       "Gagal mengambil foto evidence",
+			"error_details": err.Error(),
+		})
+		return
+	}
+
+	var notes []models.TaskEvidenceNote
+	if err := h.DB.Where("task_assign_id = ?", assign.ID).
+		Order("id ASC").
+		Find(&notes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":         true,
+			"message":       "Gagal mengambil catatan evidence",
+			"error_details": err.Error(),
+		})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"error":   false,
 		"message": "Task evidence berhasil diambil",
-		"data":    evidence,
+		"data": gin.H{
+			"evidence":    evidence,
+			"photos":      photos,
+			"notes":       notes,
+			"task_status": assign.Status,
+			"can_edit":    canEditEvidence(assign.Status),
+		},
+	})
+}
+
+// DeleteTaskEvidencePhoto - DELETE /api/v1/task-evidence/:id/photos/:type/:index
+// Menghapus satu foto before / after (index mulai 0) selama task belum diverifikasi.
+func (h *TaskEvidenceHandler) DeleteTaskEvidencePhoto(c *gin.Context) {
+	assign, ok := h.loadEvidenceAssign(c)
+	if !ok {
+		return
+	}
+
+	column := ""
+	switch c.Param("type") {
+	case "before":
+		column = "before_photos"
+	case "after":
+		column = "after_photos"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": "Tipe foto harus before atau after",
+		})
+		return
+	}
+
+	index, err := strconv.Atoi(c.Param("index"))
+	if err != nil || index < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": "Index foto tidak valid",
+		})
+		return
+	}
+
+	if !canEditEvidence(assign.Status) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   true,
+			"message": "Task sudah " + assign.Status + ", foto tidak bisa dihapus",
+		})
+		return
+	}
+
+	var removed string
+	var remaining models.JSONStringList
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		var evidence struct {
+			ID     int
+			Photos models.JSONStringList
+		}
+
+		if err := tx.Raw(`
+			SELECT id, `+column+` AS photos
+			FROM task_evidence
+			WHERE task_assign_id = ?
+			LIMIT 1
+			FOR UPDATE
+		`, assign.ID).Scan(&evidence).Error; err != nil {
+			return err
+		}
+
+		if evidence.ID == 0 || index >= len(evidence.Photos) {
+			return errEvidencePhotoNotFound
+		}
+
+		removed = evidence.Photos[index]
+		remaining = append(models.JSONStringList{}, evidence.Photos[:index]...)
+		remaining = append(remaining, evidence.Photos[index+1:]...)
+
+		if err := tx.Exec(`UPDATE task_evidence SET `+column+` = ?, updated_at = ? WHERE id = ?`,
+			remaining, time.Now(), evidence.ID).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("task_assign_id = ? AND phase = ? AND url = ?", assign.ID, c.Param("type"), removed).
+			Delete(&models.TaskEvidencePhoto{}).Error
+	})
+
+	if errors.Is(err, errEvidencePhotoNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   true,
+			"message": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":         true,
+			"message":       "Gagal menghapus foto evidence",
+			"error_details": err.Error(),
+		})
+		return
+	}
+
+	if err := storage.RemovePublicFile(removed); err != nil {
+		log.Printf("⚠️ Gagal menghapus file evidence %s: %v", removed, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"error":   false,
+		"message": "Foto evidence berhasil dihapus",
+		"data": gin.H{
+			"task_assign_id": assign.ID,
+			"type":           c.Param("type"),
+			"removed":        removed,
+			"photos":         remaining,
+		},
 	})
 }
+
+var errEvidencePhotoNotFound = errors.New("foto evidence tidak ditemukan")
+
+// canEditEvidence evidence boleh diubah selama task belum diverifikasi atau dibatalkan
+func canEditEvidence(status string) bool {
+	return status != models.TaskStatusVerified && status != models.TaskStatusCancelled
+}
+
+// loadEvidenceAssign mengambil task_assign dari path :id untuk guard pemiliknya atau koordinator branch-nya
+func (h *TaskEvidenceHandler) loadEvidenceAssign(c *gin.Context) (models.TaskAssign, bool) {
+	var assign models.TaskAssign
+
+	taskAssignID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || taskAssignID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   true,
+			"message": "ID task assign tidak valid",
+		})
+		return assign, false
+	}
+
+	err = h.DB.First(&assign, taskAssignID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   true,
+			"message": "Task assign tidak ditemukan",
+		})
+		return assign, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":         true,
+			"message":       "Gagal mengambil task assign",
+			"error_details": err.Error(),
+		})
+		return assign, false
+	}
+
+	user, _ := middleware.CurrentUser(c)
+	branchID, err := tasks.AssignBranchID(h.DB, taskAssignID)
+	if err != nil || (assign.UserTadID != uint(user.ID) && !(user.IsSupervisor() && user.CanAccessBranch(branchID))) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   true,
+			"message": "Tidak memiliki akses ke task ini",
+		})
+		return assign, false
+	}
+
+	return assign, true
+}
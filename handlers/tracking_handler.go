@@ -0,0 +1,317 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"api_patroliku_docker/database"
+	"api_patroliku_docker/middleware"
+	"api_patroliku_docker/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// maxPingsPerBatch batas jumlah titik dalam satu request
+	maxPingsPerBatch = 500
+	// trackSimplifyThreshold track dengan titik lebih dari ini otomatis disederhanakan
+	trackSimplifyThreshold = 300
+	// defaultTrackToleranceMeters toleransi default penyederhanaan track
+	defaultTrackToleranceMeters = 10.0
+	// pingClockSkew toleransi jam device yang sedikit lebih cepat dari server
+	pingClockSkew = 5 * time.Minute
+)
+
+type TrackingHandler struct {
+	DB *gorm.DB
+}
+
+func NewTrackingHandler() *TrackingHandler {
+	return &TrackingHandler{
+		DB: database.GetDB(),
+	}
+}
+
+// activeAttendance attendance user yang sudah check-in tapi belum check-out
+func (h *TrackingHandler) activeAttendance(userID int) (*models.UserAttendance, error) {
+	var attendance models.UserAttendance
+
+	// batas 26 jam supaya shift malam yang melewati tengah malam tetap terhitung aktif
+	err := h.DB.
+		Where("users_id = ? AND check_in IS NOT NULL AND check_out IS NULL AND deleted_at IS NULL", userID).
+		Where("check_in >= ?", time.Now().Add(-26*time.Hour)).
+		Order("check_in DESC").
+		First(&attendance).Error
+
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &attendance, nil
+}
+
+// StorePings - POST /api/v1/tracking/pings
+func (h *TrackingHandler) StorePings(c *gin.Context) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  "error",
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	var req models.LocationPingBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Data request tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if len(req.Pings) > maxPingsPerBatch {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Maksimal " + strconv.Itoa(maxPingsPerBatch) + " titik per request",
+		})
+		return
+	}
+
+	attendance, err := h.activeAttendance(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal memeriksa attendance",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if attendance == nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"message": "Tidak ada shift aktif, lakukan check-in terlebih dahulu",
+		})
+		return
+	}
+
+	// ===== filter titik yang valid di dalam sesi attendance =====
+	latest := time.Now().Add(pingClockSkew)
+	pings := make([]models.LocationPing, 0, len(req.Pings))
+	rejected := 0
+
+	for _, p := range req.Pings {
+		if validateCoordinate(p.Latitude, p.Longitude) != nil ||
+			p.RecordedAt.Before(*attendance.CheckIn) ||
+			p.RecordedAt.After(latest) {
+			rejected++
+			continue
+		}
+
+		pings = append(pings, models.LocationPing{
+			UserID:       uint(user.ID),
+			AttendanceID: attendance.ID,
+			Latitude:     p.Latitude,
+			Longitude:    p.Longitude,
+			Accuracy:     p.Accuracy,
+			RecordedAt:   p.RecordedAt,
+		})
+	}
+
+	var stored int64
+	if len(pings) > 0 {
+		// titik yang sama (user + recorded_at) dari retry aplikasi diabaikan
+		result := h.DB.Clauses(clause.OnConflict{DoNothing: true}).
+			CreateInBatches(&pings, 100)
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"message": "Gagal menyimpan lokasi",
+				"error":   result.Error.Error(),
+			})
+			return
+		}
+		stored = result.RowsAffected
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Lokasi berhasil disimpan",
+		"data": gin.H{
+			"attendance_id": attendance.ID,
+			"received":      len(req.Pings),
+			"stored":        stored,
+			"duplicate":     int64(len(pings)) - stored,
+			"rejected":      rejected,
+		},
+	})
+}
+
+// GetTrack - GET /api/v1/tracking/track?user_id=258&date=2025-12-12 atau ?attendance_id=10
+// Mengembalikan track shift dalam format GeoJSON FeatureCollection
+func (h *TrackingHandler) GetTrack(c *gin.Context) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":  "error",
+			"message": "Unauthorized",
+		})
+		return
+	}
+
+	// ===== cari attendance yang diminta =====
+	var attendance models.UserAttendance
+	query := h.DB.Where("deleted_at IS NULL")
+
+	if attendanceID := c.Query("attendance_id"); attendanceID != "" {
+		query = query.Where("id = ?", attendanceID)
+	} else {
+		userID, err := strconv.Atoi(c.Query("user_id"))
+		if err != nil || userID <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "attendance_id atau user_id + date wajib diisi",
+			})
+			return
+		}
+
+		date, err := time.Parse("2006-01-02", c.DefaultQuery("date", time.Now().Format("2006-01-02")))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "Format date harus YYYY-MM-DD",
+			})
+			return
+		}
+
+		query = query.Where("users_id = ? AND date_attendence = ?", userID, date)
+	}
+
+	if err := query.First(&attendance).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"status":  "error",
+				"message": "Attendance tidak ditemukan",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil attendance",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	// ===== akses: guard sendiri atau koordinator di branch guard =====
+	if int(attendance.UserID) != user.ID {
+		branchID, err := lookupUserBranchID(h.DB, int(attendance.UserID))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"message": "Gagal mengambil branch user",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		if !user.IsSupervisor() || !user.CanAccessBranch(branchID) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"status":  "error",
+				"message": "Anda tidak memiliki akses ke track user ini",
+			})
+			return
+		}
+	}
+
+	// ===== ambil titik =====
+	var pings []models.LocationPing
+	if err := h.DB.Where("attendance_id = ?", attendance.ID).
+		Order("recorded_at ASC").
+		Find(&pings).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Gagal mengambil lokasi",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	points := make([]geoPoint, 0, len(pings))
+	for _, p := range pings {
+		points = append(points, geoPoint{Lat: p.Latitude, Lng: p.Longitude, Time: p.RecordedAt})
+	}
+
+	// ===== simplifikasi track panjang =====
+	tolerance := defaultTrackToleranceMeters
+	if t, err := strconv.ParseFloat(c.Query("tolerance"), 64); err == nil && t >= 0 {
+		tolerance = t
+	}
+
+	simplified := points
+	if c.Query("simplify") == "true" || (c.Query("simplify") != "false" && len(points) > trackSimplifyThreshold) {
+		simplified = simplifyTrack(points, tolerance)
+	}
+
+	coordinates := make([][]float64, 0, len(simplified))
+	timestamps := make([]time.Time, 0, len(simplified))
+	for _, p := range simplified {
+		// GeoJSON memakai urutan [longitude, latitude]
+		coordinates = append(coordinates, []float64{p.Lng, p.Lat})
+		timestamps = append(timestamps, p.Time)
+	}
+
+	properties := gin.H{
+		"user_id":          attendance.UserID,
+		"attendance_id":    attendance.ID,
+		"date":             attendance.DateAttendance.Format("2006-01-02"),
+		"check_in":         attendance.CheckIn,
+		"check_out":        attendance.CheckOut,
+		"point_count":      len(points),
+		"simplified_count": len(simplified),
+		"tolerance_meters": tolerance,
+		"distance_meters":  trackDistance(points),
+		"timestamps":       timestamps,
+	}
+
+	features := []gin.H{}
+	if len(coordinates) > 0 {
+		geometry := gin.H{"type": "LineString", "coordinates": coordinates}
+		if len(coordinates) == 1 {
+			geometry = gin.H{"type": "Point", "coordinates": coordinates[0]}
+		}
+
+		features = append(features, gin.H{
+			"type":       "Feature",
+			"geometry":   geometry,
+			"properties": properties,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"type":       "FeatureCollection",
+		"features":   features,
+		"properties": properties,
+	})
+}
+
+// lookupUserBranchID mengambil branch user dari user_tad_information
+func lookupUserBranchID(db *gorm.DB, userID int) (int, error) {
+	var branchID int
+	err := db.Raw(`
+		SELECT COALESCE(branch_id, 0)
+		FROM user_tad_information
+		WHERE user_id = ?
+		LIMIT 1
+	`, userID).Scan(&branchID).Error
+
+	return branchID, err
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"api_patroliku_docker/database"
+	"api_patroliku_docker/models"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -67,6 +68,11 @@ func (h *UserAttendanceHandler) GetUserAttendanceToday(c *gin.Context) {
 		CheckOutSchedule *string `json:"check_out_schedule"`
 		CreatedDate      string  `json:"created_date"`
 
+		AttendanceStatus      int    `json:"attendance_status"`
+		AttendanceStatusLabel string `json:"attendance_status_label"`
+		LeaveID               *int   `json:"leave_id"`
+		OnLeave               bool   `json:"on_leave"`
+
 		IsLate       bool   `json:"is_late"`
 		LateSeconds  int64  `json:"late_seconds"`
 		LateMinutes  int    `json:"late_minutes"`
@@ -86,7 +92,9 @@ func (h *UserAttendanceHandler) GetUserAttendanceToday(c *gin.Context) {
 			ss.name AS nama_shift,
 			ss.start_time AS check_in_schedule,
 			ss.end_time AS check_out_schedule,
-			DATE(ua.created_at) AS created_date
+			DATE(ua.created_at) AS created_date,
+			ua.attendence_status_id AS attendance_status,
+			ua.leave_id
 		FROM user_attendence ua 
 		LEFT JOIN schedule s ON s.id = ua.schedule_id
 		LEFT JOIN schedule_shift ss ON ss.id = s.schedule_shift_id 
@@ -94,11 +102,14 @@ func (h *UserAttendanceHandler) GetUserAttendanceToday(c *gin.Context) {
 		LEFT JOIN user_tad_information uti ON u.id = uti.user_id 
 		LEFT JOIN branch b ON b.id = uti.branch_id 
 		WHERE u.id = ?
-		  AND DATE(ua.created_at) = ?
+		  AND (
+			(ua.leave_id IS NULL AND DATE(ua.created_at) = ?)
+			OR (ua.leave_id IS NOT NULL AND ua.date_attendence = ?)
+		  )
 		LIMIT 1
 	`
 
-	if err := h.DB.Raw(query, userID, today).
+	if err := h.DB.Raw(query, userID, today, today).
 		Scan(&data).Error; err != nil {
 
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -146,6 +157,7 @@ func (h *UserAttendanceHandler) GetUserAttendanceToday(c *gin.Context) {
 	// DATA ADA
 	// =====================================================
 	data.HasAttendance = true
+	data.AttendanceStatusLabel = models.AttendanceLabel(data.AttendanceStatus, data.CheckInUser != nil)
 
 	// ===== convert jam check-in & check-out =====
 	if data.CheckInUser != nil {
@@ -160,6 +172,19 @@ func (h *UserAttendanceHandler) GetUserAttendanceToday(c *gin.Context) {
 	data.LateDuration = "00:00:00"
 	data.LateHuman = "Tepat waktu"
 
+	// ===== hari leave (Sakit / Izin) bukan keterlambatan =====
+	if data.LeaveID != nil && data.CheckInUser == nil {
+		data.OnLeave = true
+		data.LateHuman = data.AttendanceStatusLabel
+
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "success",
+			"message": "Attendance hari ini berhasil diambil",
+			"data":    data,
+		})
+		return
+	}
+
 	// ===== hitung keterlambatan =====
 	if data.CheckInUser != nil && data.CheckInSchedule != nil {
 		scheduleTime, err := time.Parse(
@@ -1,13 +1,20 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"api_patroliku_docker/database"
+	"api_patroliku_docker/handlers"
+	taskHandler "api_patroliku_docker/handlers/task"
 	"api_patroliku_docker/routes"
 
 	"github.com/gin-gonic/gin"
@@ -24,8 +31,15 @@ func main() {
 	if err := database.ConnectDatabase(); err != nil {
 		log.Printf("⚠️ Database connection failed: %v", err)
 		log.Println("📱 App will run without database connection")
+	} else if err := database.Migrate(); err != nil {
+		log.Printf("⚠️ Database migration failed: %v", err)
 	}
 
+	// Background workers berhenti saat server dimatikan (SIGINT / SIGTERM)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	startWorkers(ctx)
+
 	// Setup Gin router
 	router := setupGinRouter()
 
@@ -36,7 +50,7 @@ func main() {
 	displayServerInfo(port, hostname, localIP)
 
 	// Start server
-	startServer(router, port)
+	startServer(ctx, router, port)
 	InitTimezone()
 }
 
@@ -164,11 +178,31 @@ func displayServerInfo(port, hostname, localIP string) {
 
 }
 
-func startServer(router *gin.Engine, port string) {
+// startWorkers menjalankan worker background: eskalasi SOS, template task rutin dan SLA task
+func startWorkers(ctx context.Context) {
+	handlers.NewSOSHandler().StartEscalationWorker(ctx)
+
+	tasks := taskHandler.NewTaskHandler()
+	tasks.StartTemplateWorker(ctx)
+	tasks.StartSLAWorker(ctx)
+}
+
+func startServer(ctx context.Context, router *gin.Engine, port string) {
 	serverAddress := fmt.Sprintf(":%s", port)
 	log.Printf("Starting server on http://localhost:%s", port)
 
-	if err := router.Run(serverAddress); err != nil {
+	server := &http.Server{Addr: serverAddress, Handler: router}
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("⚠️ Gagal menghentikan server: %v", err)
+		}
+	}()
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
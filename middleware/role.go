@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Nama user_type (tanpa membedakan huruf besar/kecil) untuk tiap peran
+var (
+	AdminUserTypes       = []string{"admin"}
+	CoordinatorUserTypes = []string{"koordinator", "coordinator", "supervisor", "danru"}
+	ClientUserTypes      = []string{"client", "klien"}
+)
+
+// AuthUser data user yang sedang login, diambil dari JWT claims
+type AuthUser struct {
+	ID       int
+	Email    string
+	UserType string
+	BranchID int
+}
+
+// CurrentUser mengambil data user yang di-set oleh AuthMiddleware
+func CurrentUser(c *gin.Context) (AuthUser, bool) {
+	value, ok := c.Get("userID")
+	if !ok {
+		return AuthUser{}, false
+	}
+	userID, ok := value.(int)
+	if !ok {
+		return AuthUser{}, false
+	}
+
+	user := AuthUser{
+		ID:       userID,
+		Email:    c.GetString("email"),
+		UserType: c.GetString("userType"),
+		BranchID: c.GetInt("branchID"),
+	}
+
+	return user, user.ID > 0
+}
+
+// IsAdmin user dengan akses ke semua branch
+func (u AuthUser) IsAdmin() bool {
+	return userTypeIs(u.UserType, AdminUserTypes...)
+}
+
+// IsCoordinator koordinator / supervisor / danru di branch
+func (u AuthUser) IsCoordinator() bool {
+	return userTypeIs(u.UserType, CoordinatorUserTypes...)
+}
+
+// IsClient kontak dari pihak client
+func (u AuthUser) IsClient() bool {
+	return userTypeIs(u.UserType, ClientUserTypes...)
+}
+
+// IsSupervisor user yang boleh mengelola data branch (koordinator atau admin)
+func (u AuthUser) IsSupervisor() bool {
+	return u.IsCoordinator() || u.IsAdmin()
+}
+
+// CanAccessBranch cek apakah user boleh melihat data branch tertentu
+func (u AuthUser) CanAccessBranch(branchID int) bool {
+	return u.IsAdmin() || u.BranchID == branchID
+}
+
+// userTypeIs nama user_type sama persis dengan salah satu nama, mis. "Supervisor Admin" bukan admin
+func userTypeIs(userType string, names ...string) bool {
+	userType = strings.TrimSpace(userType)
+	for _, name := range names {
+		if strings.EqualFold(userType, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// SupervisorOnly membatasi endpoint hanya untuk koordinator / admin
+func SupervisorOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := CurrentUser(c)
+		if !ok || !user.IsSupervisor() {
+			c.JSON(http.StatusForbidden, gin.H{
+				"status":  "error",
+				"message": "Hanya koordinator yang dapat mengakses endpoint ini",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// AdminOnly membatasi endpoint hanya untuk admin, mis. data master yang berlaku di semua branch
+func AdminOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := CurrentUser(c)
+		if !ok || !user.IsAdmin() {
+			c.JSON(http.StatusForbidden, gin.H{
+				"status":  "error",
+				"message": "Hanya admin yang dapat mengakses endpoint ini",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
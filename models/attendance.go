@@ -228,10 +228,35 @@ type AttendanceSaveResponse struct {
 	LatitudeCheckIn   float64   `json:"latitude_check_in,omitempty"`
 	LongitudeCheckOut float64   `json:"longitude_check_out,omitempty"`
 	LatitudeCheckOut  float64   `json:"latitude_check_out,omitempty"`
+	HolidayPremium    bool      `json:"holiday_premium"`
+	HolidayID         *uint     `json:"holiday_id"`
 	CreatedAt         time.Time `json:"created_at"`
 	UpdatedAt         time.Time `json:"updated_at"`
 }
 
+// Status attendance di kolom user_attendence.attendence_status_id, sama dengan kode SaveAttendance
+const (
+	AttendanceStatusHadir = 1
+	AttendanceStatusSakit = 2
+	AttendanceStatusIzin  = 3
+)
+
+// AttendanceStatusLabel label status attendance untuk response
+var AttendanceStatusLabel = map[int]string{
+	AttendanceStatusHadir: "Hadir",
+	AttendanceStatusSakit: "Sakit",
+	AttendanceStatusIzin:  "Izin",
+}
+
+// AttendanceLabel label status attendance sebuah baris. Check-out lama menulis status 2 pada baris
+// yang sudah check-in, sehingga baris dengan check-in selalu Hadir.
+func AttendanceLabel(status int, checkedIn bool) string {
+	if checkedIn {
+		return AttendanceStatusLabel[AttendanceStatusHadir]
+	}
+	return AttendanceStatusLabel[status]
+}
+
 // UserAttendance - Model untuk tabel user_attendence
 type UserAttendance struct {
 	ID                uint       `gorm:"column:id;primaryKey;autoIncrement"`
@@ -249,6 +274,9 @@ type UserAttendance struct {
 	UpdatedAt         time.Time  `gorm:"column:updated_at;autoUpdateTime"`
 	DeletedAt         *time.Time `gorm:"column:deleted_at"`
 	DocumentsClock    JSONMap    `gorm:"column:documents_clock_out;type:json"`
+	LeaveID           *uint      `gorm:"column:leave_id"` // terisi jika dibuat dari leave yang disetujui
+	HolidayPremium    bool       `gorm:"column:holiday_premium"`
+	HolidayID         *uint      `gorm:"column:holiday_id"` // hari libur kalender saat shift dijalani
 }
 
 // JSONMap - Custom type untuk field JSON
@@ -35,15 +35,17 @@ type BranchResponse struct {
 }
 
 type ScheduleResponse struct {
-	IDShift      int            `json:"id_shift"`
-	IDSchedule   int            `json:"id_schedule"`
-	Shift        string         `json:"shift"`
-	CheckinTime  string         `json:"checkin_time"`
-	CheckoutTime string         `json:"checkout_time"`
-	Branch       BranchResponse `json:"branch"`
+	IDShift        int            `json:"id_shift"`
+	IDSchedule     int            `json:"id_schedule"`
+	Shift          string         `json:"shift"`
+	CheckinTime    string         `json:"checkin_time"`
+	CheckoutTime   string         `json:"checkout_time"`
+	HolidayPremium bool           `json:"holiday_premium"` // shift di hari libur kalender
+	Branch         BranchResponse `json:"branch"`
 }
 
 type AttendanceTodayResponse struct {
-	Holiday  bool              `json:"holiday"`
-	Schedule *ScheduleResponse `json:"schedule"`
+	Holiday     bool              `json:"holiday"`
+	HolidayName string            `json:"holiday_name,omitempty"` // nama hari libur kalender
+	Schedule    *ScheduleResponse `json:"schedule"`
 }
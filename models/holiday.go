@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// Cakupan hari libur di kalender
+const (
+	HolidayScopeNational = "national" // semua branch
+	HolidayScopeRegional = "regional" // branch dengan branch.region yang sama
+	HolidayScopeCompany  = "company"  // branch milik company tertentu
+)
+
+// Sumber data hari libur
+const (
+	HolidaySourceManual = "manual"
+	HolidaySourceICal   = "ical"
+)
+
+// HolidayCalendar - Hari libur nasional, daerah atau perusahaan.
+// Shift yang dijalani di hari libur ditandai untuk perhitungan premi.
+type HolidayCalendar struct {
+	ID        uint       `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Date      time.Time  `gorm:"column:date;type:date;uniqueIndex:uq_holiday_calendar" json:"date"`
+	Name      string     `gorm:"column:name;size:200;uniqueIndex:uq_holiday_calendar" json:"name"`
+	Scope     string     `gorm:"column:scope;size:20;uniqueIndex:uq_holiday_calendar" json:"scope"`
+	Region    string     `gorm:"column:region;size:100;uniqueIndex:uq_holiday_calendar" json:"region"` // regional
+	CompanyID uint       `gorm:"column:company_id;uniqueIndex:uq_holiday_calendar" json:"company_id"`  // company, 0 = bukan holiday company
+	Source    string     `gorm:"column:source;size:10" json:"source"`
+	UID       string     `gorm:"column:uid;size:255" json:"uid"` // UID event iCal
+	CreatedBy uint       `gorm:"column:created_by" json:"created_by"`
+	CreatedAt time.Time  `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time  `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+	DeletedAt *time.Time `gorm:"column:deleted_at" json:"-"`
+}
+
+func (HolidayCalendar) TableName() string {
+	return "holiday_calendar"
+}
+
+// HolidayRequest - Body menambah hari libur
+type HolidayRequest struct {
+	Date      string `json:"date" binding:"required"` // YYYY-MM-DD
+	Name      string `json:"name" binding:"required"`
+	Scope     string `json:"scope" binding:"required,oneof=national regional company"`
+	Region    string `json:"region"`     // regional
+	CompanyID uint   `json:"company_id"` // company, koordinator otomatis company branch sendiri
+}
+
+// HolidayImportRequest - Form import kalender iCal (file .ics di field "file")
+type HolidayImportRequest struct {
+	Scope     string `form:"scope"` // default national
+	Region    string `form:"region"`
+	CompanyID uint   `form:"company_id"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+// Status incident
+const (
+	IncidentStatusOpen         = "open"
+	IncidentStatusAcknowledged = "acknowledged"
+	IncidentStatusResolved     = "resolved"
+)
+
+// IncidentCategories kategori incident yang bisa dilaporkan
+var IncidentCategories = map[string]string{
+	"break_in":          "Pembobolan / Pencurian",
+	"fire":              "Kebakaran",
+	"suspicious_person": "Orang Mencurigakan",
+	"vandalism":         "Perusakan",
+	"accident":          "Kecelakaan",
+	"medical":           "Kondisi Medis",
+	"other":             "Lainnya",
+}
+
+// IncidentSeverities tingkat keparahan incident, dari rendah ke tinggi
+var IncidentSeverities = []string{"low", "medium", "high", "critical"}
+
+// Incident - Model untuk tabel incident
+type Incident struct {
+	ID             uint           `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	UserID         uint           `gorm:"column:user_id;index" json:"user_id"`
+	BranchID       uint           `gorm:"column:branch_id;index" json:"branch_id"`
+	IDPatroli      *uint          `gorm:"column:id_patroli" json:"id_patroli"`
+	Category       string         `gorm:"column:category;size:50" json:"category"`
+	Severity       string         `gorm:"column:severity;size:20" json:"severity"`
+	Title          string         `gorm:"column:title;size:255" json:"title"`
+	Description    string         `gorm:"column:description;type:text" json:"description"`
+	Latitude       *float64       `gorm:"column:latitude" json:"latitude"`
+	Longitude      *float64       `gorm:"column:longitude" json:"longitude"`
+	Photos         JSONStringList `gorm:"column:photos;type:json" json:"photos"`
+	VideoURL       *string        `gorm:"column:video_url" json:"video_url"`
+	Status         string         `gorm:"column:status;size:20;index" json:"status"`
+	AcknowledgedBy *uint          `gorm:"column:acknowledged_by" json:"acknowledged_by"`
+	AcknowledgedAt *time.Time     `gorm:"column:acknowledged_at" json:"acknowledged_at"`
+	ResolvedBy     *uint          `gorm:"column:resolved_by" json:"resolved_by"`
+	ResolvedAt     *time.Time     `gorm:"column:resolved_at" json:"resolved_at"`
+	CreatedAt      time.Time      `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time      `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+	DeletedAt      *time.Time     `gorm:"column:deleted_at" json:"-"`
+}
+
+func (Incident) TableName() string {
+	return "incident"
+}
+
+// IncidentComment - Komentar / catatan follow-up pada incident
+type IncidentComment struct {
+	ID         uint      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	IncidentID uint      `gorm:"column:incident_id;index" json:"incident_id"`
+	UserID     uint      `gorm:"column:user_id" json:"user_id"`
+	Comment    string    `gorm:"column:comment;type:text" json:"comment"`
+	StatusTo   string    `gorm:"column:status_to;size:20" json:"status_to,omitempty"` // diisi jika komentar menyertai perubahan status
+	CreatedAt  time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+}
+
+func (IncidentComment) TableName() string {
+	return "incident_comment"
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// JSONStringList - Custom type untuk field JSON array of string (mis. daftar URL foto)
+type JSONStringList []string
+
+// Scan - Implement scanner untuk JSONStringList
+func (j *JSONStringList) Scan(value interface{}) error {
+	if value == nil {
+		*j = nil
+		return nil
+	}
+
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan type %T into JSONStringList", value)
+	}
+
+	if len(bytes) == 0 {
+		*j = nil
+		return nil
+	}
+
+	var result []string
+	if err := json.Unmarshal(bytes, &result); err != nil {
+		return err
+	}
+
+	*j = result
+	return nil
+}
+
+// Value - Implement valuer untuk JSONStringList
+func (j JSONStringList) Value() (driver.Value, error) {
+	if j == nil {
+		return nil, nil
+	}
+	return json.Marshal(j)
+}
@@ -2,6 +2,22 @@ package models
 
 import "time"
 
+// Status leave di kolom leave_new.status
+const (
+	LeaveStatusPending   = "1"
+	LeaveStatusApproved  = "2"
+	LeaveStatusRejected  = "3"
+	LeaveStatusCancelled = "4"
+)
+
+// LeaveStatusLabel label status leave untuk response
+var LeaveStatusLabel = map[string]string{
+	LeaveStatusPending:   "Pending",
+	LeaveStatusApproved:  "Approved",
+	LeaveStatusRejected:  "Rejected",
+	LeaveStatusCancelled: "Cancelled",
+}
+
 type Leave struct {
 	ID                 uint      `gorm:"primaryKey;column:id"`
 	LeaveTypeID        uint      `gorm:"column:leave_type_id"`
@@ -20,6 +36,11 @@ type Leave struct {
 	Note               string    `gorm:"column:note"`
 	NoteApproval       string    `gorm:"column:note_approval"`
 	Status             string    `gorm:"column:status"`
+	ApprovalStep       int       `gorm:"column:approval_step"` // step approval yang sedang ditunggu (mulai dari 1)
+	Days               int       `gorm:"column:days"`
+	DaysNextYear       int       `gorm:"column:days_next_year"`   // bagian Days yang jatuh di tahun date_end jika melewati pergantian tahun
+	DeductedDays       int       `gorm:"column:deducted_days"`    // hari yang dipotong dari saldo saat disetujui
+	SpecialApproval    bool      `gorm:"column:special_approval"` // melebihi saldo, butuh approval admin tambahan
 	CreatedAt          time.Time
 	UpdatedAt          time.Time
 	DeletedAt          *time.Time `gorm:"index"`
@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// Peran approver di rantai approval leave
+const (
+	LeaveApproverCoordinator = "coordinator"
+	LeaveApproverClient      = "client"
+	LeaveApproverAdmin       = "admin"
+)
+
+// Aksi yang dicatat di riwayat approval leave
+const (
+	LeaveActionApproved  = "approved"
+	LeaveActionRejected  = "rejected"
+	LeaveActionCancelled = "cancelled"
+)
+
+// DefaultLeaveApprovalChain dipakai jika company belum mengatur rantai approval sendiri
+var DefaultLeaveApprovalChain = []string{LeaveApproverCoordinator, LeaveApproverClient}
+
+// LeaveApprovalChain - Urutan approver leave per company
+type LeaveApprovalChain struct {
+	ID           uint      `gorm:"column:id;primaryKey;autoIncrement"`
+	CompanyID    uint      `gorm:"column:company_id;uniqueIndex:idx_leave_chain_company_step,priority:1"`
+	StepOrder    int       `gorm:"column:step_order;uniqueIndex:idx_leave_chain_company_step,priority:2"`
+	ApproverRole string    `gorm:"column:approver_role;size:20"`
+	CreatedAt    time.Time `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt    time.Time `gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (LeaveApprovalChain) TableName() string {
+	return "leave_approval_chain"
+}
+
+// LeaveApproval - Riwayat setiap keputusan pada leave
+type LeaveApproval struct {
+	ID           uint      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	LeaveID      uint      `gorm:"column:leave_id;index" json:"leave_id"`
+	Step         int       `gorm:"column:step" json:"step"`
+	ApproverRole string    `gorm:"column:approver_role;size:20" json:"approver_role"`
+	ApproverID   uint      `gorm:"column:approver_id" json:"approver_id"`
+	Action       string    `gorm:"column:action;size:20" json:"action"`
+	Note         string    `gorm:"column:note;type:text" json:"note"`
+	CreatedAt    time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+}
+
+func (LeaveApproval) TableName() string {
+	return "leave_approval"
+}
+
+// LeaveDecisionRequest - Body approve / reject / cancel leave
+type LeaveDecisionRequest struct {
+	Note string `json:"note"`
+}
+
+// LeaveApprovalChainRequest - Body untuk mengatur rantai approval company
+type LeaveApprovalChainRequest struct {
+	CompanyID uint     `json:"company_id" binding:"required"`
+	Steps     []string `json:"steps" binding:"required,min=1"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// Kebijakan jika pengajuan melebihi sisa saldo leave
+const (
+	LeaveExceedReject          = "reject"
+	LeaveExceedSpecialApproval = "special_approval"
+)
+
+// LeaveQuota - Jatah tahunan per jenis leave per company
+type LeaveQuota struct {
+	ID           uint      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	CompanyID    uint      `gorm:"column:company_id;uniqueIndex:idx_leave_quota_company_type,priority:1" json:"company_id"`
+	LeaveTypeID  uint      `gorm:"column:leave_type_id;uniqueIndex:idx_leave_quota_company_type,priority:2" json:"leave_type_id"`
+	AnnualDays   int       `gorm:"column:annual_days" json:"annual_days"`
+	MaxCarryOver int       `gorm:"column:max_carry_over" json:"max_carry_over"` // sisa tahun lalu yang boleh dibawa
+	ExceedPolicy string    `gorm:"column:exceed_policy;size:20;default:reject" json:"exceed_policy"`
+	CreatedAt    time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+func (LeaveQuota) TableName() string {
+	return "leave_quota"
+}
+
+// LeaveBalance - Saldo leave user per jenis leave per tahun
+type LeaveBalance struct {
+	ID          uint      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	UserID      uint      `gorm:"column:user_id;uniqueIndex:idx_leave_balance_user_type_year,priority:1" json:"user_id"`
+	LeaveTypeID uint      `gorm:"column:leave_type_id;uniqueIndex:idx_leave_balance_user_type_year,priority:2" json:"leave_type_id"`
+	Year        int       `gorm:"column:year;uniqueIndex:idx_leave_balance_user_type_year,priority:3" json:"year"`
+	Entitled    int       `gorm:"column:entitled" json:"entitled"`
+	CarriedOver int       `gorm:"column:carried_over" json:"carried_over"`
+	Used        int       `gorm:"column:used" json:"used"`
+	CreatedAt   time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+func (LeaveBalance) TableName() string {
+	return "leave_balance"
+}
+
+// Remaining sisa hari yang masih bisa dipakai
+func (b LeaveBalance) Remaining() int {
+	return b.Entitled + b.CarriedOver - b.Used
+}
+
+// LeaveQuotaRequest - Body untuk mengatur jatah leave company
+type LeaveQuotaRequest struct {
+	CompanyID    uint   `json:"company_id" binding:"required"`
+	LeaveTypeID  uint   `json:"leave_type_id" binding:"required"`
+	AnnualDays   int    `json:"annual_days" binding:"min=0"`
+	MaxCarryOver int    `json:"max_carry_over" binding:"min=0"`
+	ExceedPolicy string `json:"exceed_policy"`
+}
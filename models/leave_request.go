@@ -1,20 +1,20 @@
 package models
 
 type LeaveSaveRequest struct {
-	UserTadID uint `json:"user_tad_id" binding:"required"`
+	UserTadID uint `json:"user_tad_id" form:"user_tad_id" binding:"required"`
 
-	LeaveTypeID       uint `json:"leave_type_id"`
-	CompanyID         uint `json:"company_id"`
-	BranchID          uint `json:"branch_id"`
-	UserClientID      uint `json:"user_client_id"`
-	UserCoordinatorID uint `json:"user_coordinator_id"`
+	LeaveTypeID       uint `json:"leave_type_id" form:"leave_type_id"`
+	CompanyID         uint `json:"company_id" form:"company_id"`
+	BranchID          uint `json:"branch_id" form:"branch_id"`
+	UserClientID      uint `json:"user_client_id" form:"user_client_id"`
+	UserCoordinatorID uint `json:"user_coordinator_id" form:"user_coordinator_id"`
 
-	TypeLeave string `json:"type_leave"`
-	Code      string `json:"code"`
+	TypeLeave string `json:"type_leave" form:"type_leave"`
+	Code      string `json:"code" form:"code"`
 
-	DateStart          string `json:"date_start"` // optional
-	DateEnd            string `json:"date_end"`   // optional
-	Note               string `json:"note"`
-	Document           string `json:"document"`
+	DateStart          string `json:"date_start" form:"date_start"`
+	DateEnd            string `json:"date_end" form:"date_end"` // kosong = sama dengan date_start
+	Note               string `json:"note" form:"note"`
+	Document           string `json:"document" form:"document"` // URL dokumen yang sudah di-host (opsional)
 	UserClientBranchID uint   `gorm:"column:user_client_branch"`
 }
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// LocationPing - Titik GPS guard selama shift (antara check-in dan check-out)
+type LocationPing struct {
+	ID           uint      `gorm:"column:id;primaryKey;autoIncrement"`
+	UserID       uint      `gorm:"column:user_id;uniqueIndex:idx_location_ping_user_time,priority:1"`
+	AttendanceID uint      `gorm:"column:attendance_id;index:idx_location_ping_attendance_time,priority:1"`
+	Latitude     float64   `gorm:"column:latitude"`
+	Longitude    float64   `gorm:"column:longitude"`
+	Accuracy     *float64  `gorm:"column:accuracy"`
+	RecordedAt   time.Time `gorm:"column:recorded_at;uniqueIndex:idx_location_ping_user_time,priority:2;index:idx_location_ping_attendance_time,priority:2"`
+	CreatedAt    time.Time `gorm:"column:created_at;autoCreateTime"`
+}
+
+func (LocationPing) TableName() string {
+	return "location_ping"
+}
+
+// LocationPingRequest - satu titik GPS yang dikirim aplikasi
+type LocationPingRequest struct {
+	Latitude   float64   `json:"latitude"`
+	Longitude  float64   `json:"longitude"`
+	Accuracy   *float64  `json:"accuracy"`
+	RecordedAt time.Time `json:"recorded_at" binding:"required"` // RFC3339
+}
+
+// LocationPingBatchRequest - kumpulan titik GPS yang dikirim sekaligus
+type LocationPingBatchRequest struct {
+	Pings []LocationPingRequest `json:"pings" binding:"required,min=1,dive"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Notification - Inbox notifikasi in-app per user
+type Notification struct {
+	ID        uint       `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	UserID    uint       `gorm:"column:user_id;index" json:"user_id"`
+	Type      string     `gorm:"column:type;size:50" json:"type"`
+	Title     string     `gorm:"column:title;size:255" json:"title"`
+	Body      string     `gorm:"column:body;type:text" json:"body"`
+	Data      JSONMap    `gorm:"column:data;type:json" json:"data"`
+	ReadAt    *time.Time `gorm:"column:read_at" json:"read_at"`
+	CreatedAt time.Time  `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+}
+
+func (Notification) TableName() string {
+	return "notification"
+}
@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// ScheduleShift - Definisi shift (tabel lama schedule_shift, kolom tambahan lewat migrationStatements)
+type ScheduleShift struct {
+	ID             uint       `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	BranchID       *uint      `gorm:"column:branch_id" json:"branch_id"` // kosong = shift umum semua branch
+	Name           string     `gorm:"column:name" json:"name"`
+	StartTime      string     `gorm:"column:start_time" json:"start_time"`
+	EndTime        string     `gorm:"column:end_time" json:"end_time"`
+	BreakMinutes   int        `gorm:"column:break_minutes" json:"break_minutes"`
+	Overnight      bool       `gorm:"column:overnight" json:"overnight"`             // berakhir di hari berikutnya
+	RequiredGuards int        `gorm:"column:required_guards" json:"required_guards"` // minimal guard per hari, 0 = coverage tidak dicek
+	DeletedAt      *time.Time `gorm:"column:deleted_at" json:"-"`
+}
+
+func (ScheduleShift) TableName() string {
+	return "schedule_shift"
+}
+
+// Schedule - Jadwal guard (tabel lama schedule): mingguan lewat day, atau bertanggal lewat date_check_in
+type Schedule struct {
+	ID              uint       `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	UsersID         uint       `gorm:"column:users_id" json:"user_id"`
+	Day             *int       `gorm:"column:day" json:"day"` // 1=Senin ... 7=Minggu
+	DateCheckIn     *time.Time `gorm:"column:date_check_in" json:"date"`
+	Holiday         bool       `gorm:"column:holiday" json:"holiday"`
+	ScheduleShiftID *uint      `gorm:"column:schedule_shift_id" json:"shift_id"`
+}
+
+func (Schedule) TableName() string {
+	return "schedule"
+}
+
+// ScheduleShiftRequest - Body membuat / mengubah shift
+type ScheduleShiftRequest struct {
+	BranchID       *uint  `json:"branch_id"` // admin; koordinator selalu branch sendiri
+	Name           string `json:"name" binding:"required"`
+	StartTime      string `json:"start_time" binding:"required"` // HH:MM
+	EndTime        string `json:"end_time" binding:"required"`   // HH:MM
+	BreakMinutes   int    `json:"break_minutes" binding:"min=0"`
+	Overnight      *bool  `json:"overnight"` // otomatis true jika end_time <= start_time
+	RequiredGuards int    `json:"required_guards" binding:"min=0"`
+}
+
+// ScheduleAssignRequest - Body menjadwalkan guard ke shift per hari dan/atau per tanggal
+type ScheduleAssignRequest struct {
+	UserID  uint     `json:"user_id" binding:"required"`
+	ShiftID uint     `json:"shift_id" binding:"required"`
+	Days    []int    `json:"days"`  // mingguan, contoh: [1,2,3,4,5]
+	Dates   []string `json:"dates"` // bertanggal, contoh: ["2025-12-24"]
+}
+
+// ScheduleCopyWeekRequest - Body menyalin jadwal bertanggal satu minggu ke minggu lain
+type ScheduleCopyWeekRequest struct {
+	BranchID   uint   `json:"branch_id"`                      // admin; koordinator selalu branch sendiri
+	SourceWeek string `json:"source_week" binding:"required"` // tanggal mana saja di minggu sumber, YYYY-MM-DD
+	TargetWeek string `json:"target_week" binding:"required"` // tanggal mana saja di minggu tujuan, YYYY-MM-DD
+	Overwrite  bool   `json:"overwrite"`                      // timpa jadwal bertanggal yang sudah ada di minggu tujuan
+}
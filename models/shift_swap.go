@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// Jenis permintaan tukar shift
+const (
+	ShiftSwapTypeSwap  = "swap"  // dua guard saling bertukar shift
+	ShiftSwapTypeCover = "cover" // guard lain menggantikan shift requester
+)
+
+// Status permintaan tukar shift
+const (
+	ShiftSwapStatusPending   = "pending"   // menunggu jawaban rekan
+	ShiftSwapStatusAccepted  = "accepted"  // rekan setuju, menunggu koordinator
+	ShiftSwapStatusDeclined  = "declined"  // ditolak rekan
+	ShiftSwapStatusApproved  = "approved"  // disetujui koordinator, roster sudah diubah
+	ShiftSwapStatusRejected  = "rejected"  // ditolak koordinator
+	ShiftSwapStatusCancelled = "cancelled" // dibatalkan requester
+)
+
+// ShiftSwapRequest - Permintaan tukar / gantikan shift antar guard di satu branch
+type ShiftSwapRequest struct {
+	ID       uint   `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Type     string `gorm:"column:type;size:10" json:"type"`
+	BranchID uint   `gorm:"column:branch_id;index" json:"branch_id"`
+	Status   string `gorm:"column:status;size:20;index" json:"status"`
+	Reason   string `gorm:"column:reason;type:text" json:"reason"`
+
+	// shift requester yang ditukar / digantikan
+	RequesterID      uint      `gorm:"column:requester_id;index" json:"requester_id"`
+	RequesterDate    time.Time `gorm:"column:requester_date;type:date" json:"requester_date"`
+	RequesterShiftID uint      `gorm:"column:requester_shift_id" json:"requester_shift_id"`
+
+	// swap: shift rekan yang diambil requester; cover: kosong
+	CounterpartID      uint       `gorm:"column:counterpart_id;index" json:"counterpart_id"`
+	CounterpartDate    *time.Time `gorm:"column:counterpart_date;type:date" json:"counterpart_date"`
+	CounterpartShiftID *uint      `gorm:"column:counterpart_shift_id" json:"counterpart_shift_id"`
+	CounterpartNote    string     `gorm:"column:counterpart_note;type:text" json:"counterpart_note"`
+	RespondedAt        *time.Time `gorm:"column:responded_at" json:"responded_at"`
+
+	ReviewedBy *uint      `gorm:"column:reviewed_by" json:"reviewed_by"`
+	ReviewedAt *time.Time `gorm:"column:reviewed_at" json:"reviewed_at"`
+	ReviewNote string     `gorm:"column:review_note;type:text" json:"review_note"`
+
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+func (ShiftSwapRequest) TableName() string {
+	return "shift_swap_request"
+}
+
+// ShiftSwapCreateRequest - Body guard mengajukan tukar / gantikan shift
+type ShiftSwapCreateRequest struct {
+	Type               string `json:"type" binding:"required,oneof=swap cover"`
+	Date               string `json:"date" binding:"required"`     // tanggal shift requester, YYYY-MM-DD
+	ShiftID            uint   `json:"shift_id" binding:"required"` // shift requester di tanggal tersebut
+	CounterpartID      uint   `json:"counterpart_id" binding:"required"`
+	CounterpartDate    string `json:"counterpart_date"`     // swap, default sama dengan date
+	CounterpartShiftID uint   `json:"counterpart_shift_id"` // swap
+	Reason             string `json:"reason"`
+}
+
+// ShiftSwapResponseRequest - Body jawaban rekan atau keputusan koordinator
+type ShiftSwapResponseRequest struct {
+	Note string `json:"note"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// Status SOS
+const (
+	SOSStatusOpen         = "open"
+	SOSStatusAcknowledged = "acknowledged"
+	SOSStatusResolved     = "resolved"
+)
+
+// Aksi yang dicatat di log SOS
+const (
+	SOSActionCreated      = "created"
+	SOSActionNotified     = "notified"
+	SOSActionEscalated    = "escalated"
+	SOSActionAcknowledged = "acknowledged"
+	SOSActionResolved     = "resolved"
+)
+
+// SOSEvent - Sinyal darurat dari guard
+type SOSEvent struct {
+	ID              uint       `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	UserID          uint       `gorm:"column:user_id;index" json:"user_id"`
+	BranchID        uint       `gorm:"column:branch_id;index" json:"branch_id"`
+	Latitude        float64    `gorm:"column:latitude" json:"latitude"`
+	Longitude       float64    `gorm:"column:longitude" json:"longitude"`
+	Note            string     `gorm:"column:note;type:text" json:"note"`
+	Status          string     `gorm:"column:status;size:20;index" json:"status"`
+	EscalationLevel int        `gorm:"column:escalation_level" json:"escalation_level"`
+	LastEscalatedAt time.Time  `gorm:"column:last_escalated_at" json:"last_escalated_at"`
+	AcknowledgedBy  *uint      `gorm:"column:acknowledged_by" json:"acknowledged_by"`
+	AcknowledgedAt  *time.Time `gorm:"column:acknowledged_at" json:"acknowledged_at"`
+	ResolvedBy      *uint      `gorm:"column:resolved_by" json:"resolved_by"`
+	ResolvedAt      *time.Time `gorm:"column:resolved_at" json:"resolved_at"`
+	ResolutionNote  string     `gorm:"column:resolution_note;type:text" json:"resolution_note"`
+	CreatedAt       time.Time  `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time  `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+func (SOSEvent) TableName() string {
+	return "sos_event"
+}
+
+// SOSEventLog - Jejak setiap langkah penanganan SOS untuk review pasca kejadian
+type SOSEventLog struct {
+	ID          uint      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	SOSEventID  uint      `gorm:"column:sos_event_id;index" json:"sos_event_id"`
+	Action      string    `gorm:"column:action;size:30" json:"action"`
+	Level       int       `gorm:"column:level" json:"level"`
+	ActorUserID *uint     `gorm:"column:actor_user_id" json:"actor_user_id"`
+	Note        string    `gorm:"column:note;type:text" json:"note"`
+	Data        JSONMap   `gorm:"column:data;type:json" json:"data"`
+	CreatedAt   time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+}
+
+func (SOSEventLog) TableName() string {
+	return "sos_event_log"
+}
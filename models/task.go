@@ -0,0 +1,159 @@
+package models
+
+import "time"
+
+// Status task_assign
+const (
+	TaskStatusAssigned   = "assigned"
+	TaskStatusInProgress = "in_progress"
+	TaskStatusSubmitted  = "submitted"
+	TaskStatusVerified   = "verified"
+	TaskStatusRejected   = "rejected"
+	TaskStatusCancelled  = "cancelled"
+	TaskStatusOverdue    = "overdue"
+)
+
+// Task - Master pekerjaan per branch
+type Task struct {
+	ID          uint       `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Name        string     `gorm:"column:name" json:"name"`
+	TaskTypeID  uint       `gorm:"column:task_type_id" json:"task_type_id"`
+	BranchID    uint       `gorm:"column:branch_id" json:"branch_id"`
+	Description string     `gorm:"column:description" json:"description"`
+	CreatedBy   uint       `gorm:"column:created_by" json:"created_by"`
+	CreatedAt   time.Time  `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+	DeletedAt   *time.Time `gorm:"column:deleted_at" json:"-"`
+}
+
+func (Task) TableName() string {
+	return "task"
+}
+
+// TaskAssign - Penugasan task ke satu guard dengan jendela waktu
+type TaskAssign struct {
+	ID         uint       `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	TaskID     uint       `gorm:"column:task_id" json:"task_id"`
+	UserTadID  uint       `gorm:"column:user_tad_id" json:"user_tad_id"`
+	Status     string     `gorm:"column:status" json:"status"`
+	Note       *string    `gorm:"column:note" json:"note"`
+	StartTime  *time.Time `gorm:"column:start_time" json:"start_time"`
+	EndTime    *time.Time `gorm:"column:end_time" json:"end_time"`
+	AssignedBy uint       `gorm:"column:assigned_by" json:"assigned_by"`
+	CreatedAt  time.Time  `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time  `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+
+	// hasil review koordinator
+	ReviewScore *int       `gorm:"column:review_score" json:"review_score"`
+	ReviewNote  *string    `gorm:"column:review_note" json:"review_note"`
+	ReviewedBy  *uint      `gorm:"column:reviewed_by" json:"reviewed_by"`
+	ReviewedAt  *time.Time `gorm:"column:reviewed_at" json:"reviewed_at"`
+
+	// waktu koordinator dikabari penugasan overdue
+	OverdueEscalatedAt *time.Time `gorm:"column:overdue_escalated_at" json:"overdue_escalated_at"`
+
+	// terisi jika penugasan dibuat dari template task rutin
+	TemplateID   *uint      `gorm:"column:template_id" json:"template_id"`
+	OccurrenceAt *time.Time `gorm:"column:occurrence_at" json:"occurrence_at"`
+}
+
+func (TaskAssign) TableName() string {
+	return "task_assign"
+}
+
+// TaskAssignHistory - Riwayat perubahan status task_assign
+type TaskAssignHistory struct {
+	ID           uint      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	TaskAssignID uint      `gorm:"column:task_assign_id;index" json:"task_assign_id"`
+	FromStatus   string    `gorm:"column:from_status;size:20" json:"from_status"`
+	ToStatus     string    `gorm:"column:to_status;size:20" json:"to_status"`
+	ActorID      *uint     `gorm:"column:actor_id" json:"actor_id"` // kosong = sistem
+	Note         string    `gorm:"column:note;type:text" json:"note"`
+	CreatedAt    time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+}
+
+func (TaskAssignHistory) TableName() string {
+	return "task_assign_history"
+}
+
+// TaskStatusRequest - Body perubahan status oleh guard / koordinator
+type TaskStatusRequest struct {
+	Note string `json:"note"`
+}
+
+// TaskReviewRequest - Body verifikasi / penolakan task oleh koordinator
+type TaskReviewRequest struct {
+	Note  string `json:"note"` // wajib saat menolak
+	Score *int   `json:"score" binding:"omitempty,min=1,max=5"`
+}
+
+// TaskCreateRequest - Body membuat task, bisa langsung ditugaskan ke guard
+type TaskCreateRequest struct {
+	Name        string `json:"name" binding:"required"`
+	TaskTypeID  uint   `json:"task_type_id" binding:"required"`
+	BranchID    uint   `json:"branch_id"` // khusus admin, default branch user login
+	Description string `json:"description"`
+
+	UserTadIDs []uint `json:"user_tad_ids"`
+	StartTime  string `json:"start_time"` // format: 2006-01-02 15:04
+	EndTime    string `json:"end_time"`
+	Note       string `json:"note"`
+}
+
+// TaskAssignRequest - Body menugaskan task ke satu atau banyak guard
+type TaskAssignRequest struct {
+	UserTadIDs []uint `json:"user_tad_ids" binding:"required,min=1"`
+	StartTime  string `json:"start_time" binding:"required"`
+	EndTime    string `json:"end_time" binding:"required"`
+	Note       string `json:"note"`
+}
+
+// TaskReassignRequest - Body memindahkan penugasan ke guard lain
+type TaskReassignRequest struct {
+	UserTadID uint   `json:"user_tad_id" binding:"required"`
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+	Note      string `json:"note"`
+}
+
+// Jenis pengulangan template task
+const (
+	TaskRecurrenceWeekly = "weekly"
+	TaskRecurrenceCron   = "cron"
+)
+
+// TaskTemplate - Task rutin per branch yang ditugaskan otomatis ke guard yang sedang shift
+type TaskTemplate struct {
+	ID              uint       `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	TaskID          uint       `gorm:"column:task_id;index" json:"task_id"`
+	BranchID        uint       `gorm:"column:branch_id;index" json:"branch_id"`
+	ShiftID         *uint      `gorm:"column:shift_id" json:"shift_id"` // kosong = semua shift yang sedang berjalan
+	Recurrence      string     `gorm:"column:recurrence;size:10" json:"recurrence"`
+	Weekdays        string     `gorm:"column:weekdays;size:20" json:"weekdays"`      // weekly: 1=Senin ... 7=Minggu, dipisah koma
+	TimeOfDay       string     `gorm:"column:time_of_day;size:5" json:"time_of_day"` // weekly: HH:MM
+	CronExpr        string     `gorm:"column:cron_expr;size:100" json:"cron_expr"`   // cron: menit jam tanggal bulan hari
+	DurationMinutes int        `gorm:"column:duration_minutes" json:"duration_minutes"`
+	Note            string     `gorm:"column:note;type:text" json:"note"`
+	Active          bool       `gorm:"column:active;default:true" json:"active"`
+	CreatedBy       uint       `gorm:"column:created_by" json:"created_by"`
+	CreatedAt       time.Time  `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time  `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+	DeletedAt       *time.Time `gorm:"column:deleted_at" json:"-"`
+}
+
+func (TaskTemplate) TableName() string {
+	return "task_template"
+}
+
+// TaskTemplateRequest - Body membuat / mengubah template task
+type TaskTemplateRequest struct {
+	TaskID          uint   `json:"task_id" binding:"required"`
+	ShiftID         *uint  `json:"shift_id"`
+	Recurrence      string `json:"recurrence" binding:"required,oneof=weekly cron"`
+	Weekdays        []int  `json:"weekdays"`    // weekly, contoh: [1,2,3,4,5]
+	TimeOfDay       string `json:"time_of_day"` // weekly, contoh: 22:00
+	CronExpr        string `json:"cron_expr"`   // cron, contoh: 0 * * * *
+	DurationMinutes int    `json:"duration_minutes" binding:"required,min=1"`
+	Note            string `json:"note"`
+	Active          *bool  `json:"active"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// Hasil item checklist
+const (
+	ChecklistResultPass = "pass"
+	ChecklistResultFail = "fail"
+	ChecklistResultNA   = "na"
+)
+
+// TaskChecklistItem - Definisi item checklist per tipe task
+type TaskChecklistItem struct {
+	ID          uint       `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	TaskTypeID  uint       `gorm:"column:task_type_id;index" json:"task_type_id"`
+	Label       string     `gorm:"column:label;size:255" json:"label"`
+	Description string     `gorm:"column:description;type:text" json:"description"`
+	Required    bool       `gorm:"column:required;default:true" json:"required"`
+	SortOrder   int        `gorm:"column:sort_order" json:"sort_order"`
+	CreatedBy   uint       `gorm:"column:created_by" json:"created_by"`
+	CreatedAt   time.Time  `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+	DeletedAt   *time.Time `gorm:"column:deleted_at" json:"-"`
+}
+
+func (TaskChecklistItem) TableName() string {
+	return "task_checklist_item"
+}
+
+// TaskChecklistResult - Jawaban guard untuk satu item checklist di satu penugasan
+type TaskChecklistResult struct {
+	ID              uint      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	TaskAssignID    uint      `gorm:"column:task_assign_id;uniqueIndex:uq_task_checklist_result" json:"task_assign_id"`
+	ChecklistItemID uint      `gorm:"column:checklist_item_id;uniqueIndex:uq_task_checklist_result" json:"checklist_item_id"`
+	Result          string    `gorm:"column:result;size:10" json:"result"`
+	Comment         string    `gorm:"column:comment;type:text" json:"comment"`
+	Photo           string    `gorm:"column:photo" json:"photo"`
+	AnsweredBy      uint      `gorm:"column:answered_by" json:"answered_by"`
+	CreatedAt       time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+func (TaskChecklistResult) TableName() string {
+	return "task_checklist_result"
+}
+
+// TaskChecklistItemRequest - Body membuat / mengubah item checklist
+type TaskChecklistItemRequest struct {
+	Label       string `json:"label" binding:"required"`
+	Description string `json:"description"`
+	Required    *bool  `json:"required"` // default true
+	SortOrder   int    `json:"sort_order"`
+}
+
+// TaskChecklistAnswerRequest - Jawaban item checklist (JSON atau multipart dengan file "photo")
+type TaskChecklistAnswerRequest struct {
+	Result  string `json:"result" form:"result" binding:"required,oneof=pass fail na"`
+	Comment string `json:"comment" form:"comment"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// TaskAssignComment - Komentar diskusi pada satu penugasan task
+type TaskAssignComment struct {
+	ID           uint           `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	TaskAssignID uint           `gorm:"column:task_assign_id;index" json:"task_assign_id"`
+	UserID       uint           `gorm:"column:user_id" json:"user_id"`
+	Comment      string         `gorm:"column:comment;type:text" json:"comment"`
+	Images       JSONStringList `gorm:"column:images;type:json" json:"images"`
+	CreatedAt    time.Time      `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+}
+
+func (TaskAssignComment) TableName() string {
+	return "task_assign_comment"
+}
+
+// TaskAssignCommentRead - Komentar terakhir yang sudah dibaca user di thread penugasan
+type TaskAssignCommentRead struct {
+	ID                uint      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	TaskAssignID      uint      `gorm:"column:task_assign_id;uniqueIndex:uq_task_assign_comment_read" json:"task_assign_id"`
+	UserID            uint      `gorm:"column:user_id;uniqueIndex:uq_task_assign_comment_read" json:"user_id"`
+	LastReadCommentID uint      `gorm:"column:last_read_comment_id" json:"last_read_comment_id"`
+	ReadAt            time.Time `gorm:"column:read_at" json:"read_at"`
+}
+
+func (TaskAssignCommentRead) TableName() string {
+	return "task_assign_comment_read"
+}
+
+// TaskCommentRequest - Body komentar (JSON atau multipart dengan file "images[]")
+type TaskCommentRequest struct {
+	Comment string `json:"comment" form:"comment"`
+}
+
+// TaskCommentReadRequest - Tandai komentar sudah dibaca sampai last_comment_id (kosong = semua)
+type TaskCommentReadRequest struct {
+	LastCommentID uint `json:"last_comment_id"`
+}
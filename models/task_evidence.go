@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Fase evidence task
+const (
+	EvidencePhaseBefore = "before"
+	EvidencePhaseAfter  = "after"
+)
+
+// TaskEvidencePhoto - Detail satu foto evidence beserta keterangannya.
+// URL juga tercatat di task_evidence.before_photos / after_photos.
+type TaskEvidencePhoto struct {
+	ID           uint      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	TaskAssignID uint      `gorm:"column:task_assign_id;index" json:"task_assign_id"`
+	Phase        string    `gorm:"column:phase;size:10" json:"phase"`
+	URL          string    `gorm:"column:url" json:"url"`
+	Caption      string    `gorm:"column:caption;type:text" json:"caption"`
+	UploadedBy   uint      `gorm:"column:uploaded_by" json:"uploaded_by"`
+	CreatedAt    time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+}
+
+func (TaskEvidencePhoto) TableName() string {
+	return "task_evidence_photo"
+}
+
+// TaskEvidenceNote - Riwayat catatan pengerjaan per upload evidence
+type TaskEvidenceNote struct {
+	ID           uint      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	TaskAssignID uint      `gorm:"column:task_assign_id;index" json:"task_assign_id"`
+	Phase        string    `gorm:"column:phase;size:10" json:"phase"`
+	Note         string    `gorm:"column:note;type:text" json:"note"`
+	UserID       uint      `gorm:"column:user_id" json:"user_id"`
+	CreatedAt    time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+}
+
+func (TaskEvidenceNote) TableName() string {
+	return "task_evidence_note"
+}
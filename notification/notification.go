@@ -0,0 +1,162 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"api_patroliku_docker/models"
+
+	"gorm.io/gorm"
+)
+
+// fcmLegacyURL endpoint push FCM, hanya dipakai jika FCM_SERVER_KEY di-set
+const fcmLegacyURL = "https://fcm.googleapis.com/fcm/send"
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// Send menyimpan notifikasi ke inbox setiap user lalu mengirim push FCM di background
+func Send(db *gorm.DB, userIDs []int, notifType, title, body string, data models.JSONMap) error {
+	userIDs = uniqueIDs(userIDs)
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	rows := make([]models.Notification, 0, len(userIDs))
+	for _, userID := range userIDs {
+		rows = append(rows, models.Notification{
+			UserID: uint(userID),
+			Type:   notifType,
+			Title:  title,
+			Body:   body,
+			Data:   data,
+		})
+	}
+
+	if err := db.Create(&rows).Error; err != nil {
+		return fmt.Errorf("gagal menyimpan notifikasi: %v", err)
+	}
+
+	go push(db, userIDs, notifType, title, body, data)
+
+	return nil
+}
+
+// BranchUsersByType mengambil id user di branch dengan nama user_type salah satu dari names
+func BranchUsersByType(db *gorm.DB, branchID int, names []string) ([]int, error) {
+	return usersByType(db, &branchID, names)
+}
+
+// UsersByType mengambil id user dengan nama user_type salah satu dari names di semua branch
+func UsersByType(db *gorm.DB, names []string) ([]int, error) {
+	return usersByType(db, nil, names)
+}
+
+// usersByType mencocokkan nama user_type persis (tanpa membedakan huruf besar/kecil),
+// sama dengan pengecekan peran di middleware
+func usersByType(db *gorm.DB, branchID *int, names []string) ([]int, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	lowered := make([]string, 0, len(names))
+	for _, name := range names {
+		lowered = append(lowered, strings.ToLower(name))
+	}
+	params := []interface{}{lowered}
+
+	query := `
+		SELECT DISTINCT u.id
+		FROM users u
+		INNER JOIN user_type ut ON ut.id = u.user_type_id
+		INNER JOIN user_tad_information uti ON uti.user_id = u.id
+		WHERE u.deleted_at IS NULL
+		  AND LOWER(TRIM(ut.name)) IN ?`
+
+	if branchID != nil {
+		query += " AND uti.branch_id = ?"
+		params = append(params, *branchID)
+	}
+
+	var ids []int
+	if err := db.Raw(query, params...).Scan(&ids).Error; err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// push mengirim push notification ke device user yang punya fcm_token
+func push(db *gorm.DB, userIDs []int, notifType, title, body string, data models.JSONMap) {
+	serverKey := os.Getenv("FCM_SERVER_KEY")
+	if serverKey == "" {
+		log.Printf("🔔 [%s] %s → user %v (push dilewati, FCM_SERVER_KEY kosong)", notifType, title, userIDs)
+		return
+	}
+
+	var tokens []string
+	if err := db.Raw(`
+		SELECT fcm_token
+		FROM users
+		WHERE id IN ? AND fcm_token IS NOT NULL AND fcm_token <> ''
+	`, userIDs).Scan(&tokens).Error; err != nil {
+		log.Printf("⚠️ Gagal mengambil fcm_token: %v", err)
+		return
+	}
+
+	if len(tokens) == 0 {
+		return
+	}
+
+	payloadData := map[string]interface{}{"type": notifType}
+	for k, v := range data {
+		payloadData[k] = v
+	}
+
+	payload, _ := json.Marshal(map[string]interface{}{
+		"registration_ids": tokens,
+		"priority":         "high",
+		"notification": map[string]string{
+			"title": title,
+			"body":  body,
+		},
+		"data": payloadData,
+	})
+
+	req, err := http.NewRequest(http.MethodPost, fcmLegacyURL, bytes.NewReader(payload))
+	if err != nil {
+		log.Printf("⚠️ Gagal membuat request FCM: %v", err)
+		return
+	}
+	req.Header.Set("Authorization", "key="+serverKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		log.Printf("⚠️ Gagal mengirim push FCM: %v", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("⚠️ Push FCM gagal dengan status %d", resp.StatusCode)
+	}
+}
+
+func uniqueIDs(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	result := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if id <= 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, id)
+	}
+	return result
+}
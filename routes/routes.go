@@ -26,6 +26,11 @@ func SetupRoutes(router *gin.Engine, port, hostname, localIP string, allIPs []st
 	leaveHandler := handlers.NewLeaveHandler()
 	patroliHandler := handlers.NewMasterPatroliHandler()
 	userAttHandler := handlers.NewUserAttendanceHandler()
+	incidentHandler := handlers.NewIncidentHandler()
+	sosHandler := handlers.NewSOSHandler()
+	trackingHandler := handlers.NewTrackingHandler()
+	feedHandler := handlers.NewFeedHandler()
+	scheduleHandler := handlers.NewScheduleHandler()
 
 	// API Routes Group - Version 1
 	apiV1 := router.Group("/api/v1")
@@ -58,21 +63,55 @@ func SetupRoutes(router *gin.Engine, port, hostname, localIP string, allIPs []st
 				attendance.POST("/check-in", attendanceHandler.CheckIn)
 				attendance.POST("/check-out", attendanceHandler.CheckOut)
 				attendance.GET("/today", attendanceHandler.GetTodayAttendance)
+				attendance.GET("/history", attendanceHandler.GetAttendanceHistory)
 			}
 
 			// Task endpoints
 			taskGroup := protected.Group("/tasks")
 			{
-				// taskGroup.GET("", taskHandler.GetTaskByUser)
 				taskGroup.GET("", taskHandler.GetTask)
+				taskGroup.GET("/branch", middleware.SupervisorOnly(), taskHandler.GetBranchTask)
 
 				taskGroup.GET("/detail/:id", taskHandler.GetTaskDetail)
+				taskGroup.GET("/types", taskHandler.GetTaskTypes)
+				taskGroup.GET("/assign/:id/history", taskHandler.GetTaskAssignHistory)
+				taskGroup.POST("/assign/:id/start", taskHandler.StartTask)
+				taskGroup.GET("/types/:id/checklist", taskHandler.GetChecklistItems)
+				taskGroup.GET("/assign/:id/checklist", taskHandler.GetTaskChecklist)
+				taskGroup.PUT("/assign/:id/checklist/:item_id", taskHandler.AnswerChecklistItem)
+				taskGroup.GET("/assign/:id/comments", taskHandler.GetTaskComments)
+				taskGroup.POST("/assign/:id/comments", taskHandler.AddTaskComment)
+				taskGroup.POST("/assign/:id/comments/read", taskHandler.MarkTaskCommentsRead)
+
+				// Pengelolaan task oleh koordinator
+				taskGroup.POST("", middleware.SupervisorOnly(), taskHandler.CreateTask)
+				taskGroup.POST("/:id/assign", middleware.SupervisorOnly(), taskHandler.AssignTask)
+				taskGroup.PUT("/assign/:id", middleware.SupervisorOnly(), taskEvidence.UpdateTaskAssign)
+				taskGroup.POST("/assign/:id/reassign", middleware.SupervisorOnly(), taskHandler.ReassignTask)
+				taskGroup.POST("/assign/:id/cancel", middleware.SupervisorOnly(), taskHandler.CancelTaskAssign)
+				taskGroup.POST("/assign/:id/verify", middleware.SupervisorOnly(), taskHandler.VerifyTask)
+				taskGroup.POST("/assign/:id/reject", middleware.SupervisorOnly(), taskHandler.RejectTask)
+				taskGroup.GET("/metrics", middleware.SupervisorOnly(), taskHandler.GetTaskMetrics)
+				taskGroup.GET("/sla-report", middleware.SupervisorOnly(), taskHandler.GetTaskSLAReport)
+
+				// Template task rutin (koordinator)
+				taskGroup.GET("/templates", middleware.SupervisorOnly(), taskHandler.GetTaskTemplates)
+				taskGroup.POST("/templates", middleware.SupervisorOnly(), taskHandler.CreateTaskTemplate)
+				taskGroup.PUT("/templates/:id", middleware.SupervisorOnly(), taskHandler.UpdateTaskTemplate)
+				taskGroup.DELETE("/templates/:id", middleware.SupervisorOnly(), taskHandler.DeleteTaskTemplate)
+
+				// Definisi checklist per tipe task, tipe task berlaku di semua branch (admin)
+				taskGroup.POST("/types/:id/checklist", middleware.AdminOnly(), taskHandler.CreateChecklistItem)
+				taskGroup.PUT("/checklist/:id", middleware.AdminOnly(), taskHandler.UpdateChecklistItem)
+				taskGroup.DELETE("/checklist/:id", middleware.AdminOnly(), taskHandler.DeleteChecklistItem)
 			}
 
 			// Task Evidence endpoints
 			taskEvidenceGroup := protected.Group("/task-evidence")
 			{
 				taskEvidenceGroup.POST("/upload", taskEvidence.UploadTaskEvidence)
+				taskEvidenceGroup.GET("/:id", taskEvidence.GetTaskEvidence)
+				taskEvidenceGroup.DELETE("/:id/photos/:type/:index", taskEvidence.DeleteTaskEvidencePhoto)
 				// taskEvidenceGroup.POST("/after", taskEvidence.UploadAfterPhoto)
 			}
 
@@ -84,6 +123,73 @@ func SetupRoutes(router *gin.Engine, port, hostname, localIP string, allIPs []st
 
 			}
 
+			incidents := protected.Group("/incidents")
+			{
+				incidents.GET("", incidentHandler.ListIncidents)
+				incidents.GET("/categories", incidentHandler.GetIncidentCategories)
+				incidents.GET("/:id", incidentHandler.GetIncidentDetail)
+				incidents.POST("", incidentHandler.CreateIncident)
+				incidents.POST("/:id/acknowledge", incidentHandler.AcknowledgeIncident)
+				incidents.POST("/:id/resolve", incidentHandler.ResolveIncident)
+				incidents.POST("/:id/comments", incidentHandler.AddIncidentComment)
+			}
+
+			sos := protected.Group("/sos")
+			{
+				sos.POST("", sosHandler.CreateSOS)
+				sos.GET("", sosHandler.ListSOS)
+				sos.GET("/:id", sosHandler.GetSOSDetail)
+				sos.POST("/:id/acknowledge", sosHandler.AcknowledgeSOS)
+				sos.POST("/:id/resolve", sosHandler.ResolveSOS)
+			}
+
+			tracking := protected.Group("/tracking")
+			{
+				tracking.POST("/pings", trackingHandler.StorePings)
+				tracking.GET("/track", trackingHandler.GetTrack)
+			}
+
+			feed := protected.Group("/feed")
+			feed.Use(middleware.SupervisorOnly())
+			{
+				feed.GET("/stream", feedHandler.Stream)
+			}
+
+			schedules := protected.Group("/schedules")
+			schedules.Use(middleware.SupervisorOnly())
+			{
+				schedules.GET("", scheduleHandler.GetSchedules)
+				schedules.POST("", scheduleHandler.AssignSchedule)
+				schedules.DELETE("/:id", scheduleHandler.DeleteSchedule)
+				schedules.POST("/copy-week", scheduleHandler.CopyWeek)
+				schedules.GET("/roster", scheduleHandler.GetRoster)
+				schedules.GET("/conflicts", scheduleHandler.GetScheduleConflicts)
+				schedules.GET("/shifts", scheduleHandler.GetShifts)
+				schedules.POST("/shifts", scheduleHandler.CreateShift)
+				schedules.PUT("/shifts/:id", scheduleHandler.UpdateShift)
+				schedules.DELETE("/shifts/:id", scheduleHandler.DeleteShift)
+			}
+
+			shiftSwaps := protected.Group("/shift-swaps")
+			{
+				shiftSwaps.GET("", scheduleHandler.GetShiftSwaps)
+				shiftSwaps.POST("", scheduleHandler.CreateShiftSwap)
+				shiftSwaps.GET("/:id", scheduleHandler.GetShiftSwapDetail)
+				shiftSwaps.POST("/:id/accept", scheduleHandler.AcceptShiftSwap)
+				shiftSwaps.POST("/:id/decline", scheduleHandler.DeclineShiftSwap)
+				shiftSwaps.POST("/:id/cancel", scheduleHandler.CancelShiftSwap)
+				shiftSwaps.POST("/:id/approve", middleware.SupervisorOnly(), scheduleHandler.ApproveShiftSwap)
+				shiftSwaps.POST("/:id/reject", middleware.SupervisorOnly(), scheduleHandler.RejectShiftSwap)
+			}
+
+			holidays := protected.Group("/holidays")
+			{
+				holidays.GET("", scheduleHandler.GetHolidays)
+				holidays.POST("", middleware.SupervisorOnly(), scheduleHandler.CreateHoliday)
+				holidays.DELETE("/:id", middleware.SupervisorOnly(), scheduleHandler.DeleteHoliday)
+				holidays.POST("/import", middleware.SupervisorOnly(), scheduleHandler.ImportHolidays)
+			}
+
 			userAtt := protected.Group("/user-att")
 			{
 				userAtt.GET("/", userAttHandler.GetUserAttendanceToday)
@@ -100,6 +206,17 @@ func SetupRoutes(router *gin.Engine, port, hostname, localIP string, allIPs []st
 			leaveRoutes := protected.Group("/leave")
 			{
 				leaveRoutes.POST("/", leaveHandler.SaveLeave)
+				leaveRoutes.GET("", leaveHandler.ListLeave)
+				leaveRoutes.GET("/approval-chain", leaveHandler.GetApprovalChain)
+				leaveRoutes.PUT("/approval-chain", leaveHandler.SetApprovalChain)
+				leaveRoutes.GET("/balance", leaveHandler.GetLeaveBalance)
+				leaveRoutes.GET("/quota", leaveHandler.GetLeaveQuota)
+				leaveRoutes.PUT("/quota", leaveHandler.SetLeaveQuota)
+				leaveRoutes.GET("/:id", leaveHandler.GetLeaveDetail)
+				leaveRoutes.POST("/:id/approve", leaveHandler.ApproveLeave)
+				leaveRoutes.POST("/:id/reject", leaveHandler.RejectLeave)
+				leaveRoutes.POST("/:id/cancel", leaveHandler.CancelLeave)
+				leaveRoutes.GET("/:id/documents/:index", leaveHandler.DownloadLeaveDocument)
 
 			}
 		}
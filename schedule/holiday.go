@@ -0,0 +1,102 @@
+package schedule
+
+import (
+	"strings"
+	"time"
+
+	"api_patroliku_docker/models"
+
+	"gorm.io/gorm"
+)
+
+// Holiday hari libur kalender yang berlaku untuk user
+type Holiday struct {
+	ID    uint   `json:"id"`
+	Name  string `json:"name"`
+	Scope string `json:"scope"`
+}
+
+type userBranch struct {
+	UserID    uint    `gorm:"column:user_id"`
+	CompanyID *uint   `gorm:"column:company_id"`
+	Region    *string `gorm:"column:region"`
+}
+
+// HolidayOn hari libur kalender yang berlaku untuk user di tanggal date, nil jika bukan hari libur
+func HolidayOn(db *gorm.DB, userID uint, date time.Time) (*Holiday, error) {
+	holidays, err := holidays(db, []uint{userID}, date, date)
+	if err != nil {
+		return nil, err
+	}
+	if holiday, ok := holidays[userID][date.Format("2006-01-02")]; ok {
+		return &holiday, nil
+	}
+	return nil, nil
+}
+
+// holidays hari libur kalender per user per tanggal di rentang [start, end].
+// Nasional berlaku untuk semua user, regional untuk branch dengan region yang sama,
+// company untuk branch milik company tersebut.
+func holidays(db *gorm.DB, userIDs []uint, start, end time.Time) (map[uint]map[string]Holiday, error) {
+	result := map[uint]map[string]Holiday{}
+	if len(userIDs) == 0 {
+		return result, nil
+	}
+
+	var calendar []models.HolidayCalendar
+	if err := db.Where("deleted_at IS NULL AND date BETWEEN ? AND ?",
+		start.Format("2006-01-02"), end.Format("2006-01-02")).
+		Order("date ASC, id ASC").
+		Find(&calendar).Error; err != nil {
+		return nil, err
+	}
+	if len(calendar) == 0 {
+		return result, nil
+	}
+
+	var branches []userBranch
+	if err := db.Raw(`
+		SELECT uti.user_id, b.company_id, b.region
+		FROM user_tad_information uti
+		LEFT JOIN branch b ON b.id = uti.branch_id
+		WHERE uti.user_id IN ?
+	`, userIDs).Scan(&branches).Error; err != nil {
+		return nil, err
+	}
+	byUser := map[uint]userBranch{}
+	for _, branch := range branches {
+		byUser[branch.UserID] = branch
+	}
+
+	for _, userID := range userIDs {
+		branch := byUser[userID]
+		for _, holiday := range calendar {
+			if !holidayApplies(holiday, branch) {
+				continue
+			}
+
+			date := holiday.Date.Format("2006-01-02")
+			if result[userID] == nil {
+				result[userID] = map[string]Holiday{}
+			}
+			if _, ok := result[userID][date]; !ok {
+				result[userID][date] = Holiday{ID: holiday.ID, Name: holiday.Name, Scope: holiday.Scope}
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func holidayApplies(holiday models.HolidayCalendar, branch userBranch) bool {
+	switch holiday.Scope {
+	case models.HolidayScopeNational:
+		return true
+	case models.HolidayScopeRegional:
+		return branch.Region != nil &&
+			strings.EqualFold(strings.TrimSpace(*branch.Region), strings.TrimSpace(holiday.Region))
+	case models.HolidayScopeCompany:
+		return branch.CompanyID != nil && *branch.CompanyID == holiday.CompanyID
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package schedule
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// maxICalEventDays batas panjang satu event iCal yang diubah menjadi hari libur
+const maxICalEventDays = 31
+
+var ErrICalEmpty = errors.New("file iCal tidak berisi event")
+
+// ICalHoliday satu hari libur dari event iCal; event beberapa hari dipecah per tanggal
+type ICalHoliday struct {
+	UID  string
+	Name string
+	Date time.Time
+}
+
+// ParseICal membaca VEVENT dari kalender iCal (mis. kalender libur nasional Indonesia).
+// DTEND bersifat eksklusif sesuai RFC 5545; event tanpa DTEND dianggap satu hari.
+func ParseICal(r io.Reader) ([]ICalHoliday, error) {
+	lines, err := unfoldICal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		holidays []ICalHoliday
+		inEvent  bool
+		uid      string
+		summary  string
+		start    *time.Time
+		end      *time.Time
+	)
+
+	for _, line := range lines {
+		name, params, value := splitICalLine(line)
+
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			inEvent, uid, summary, start, end = true, "", "", nil, nil
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			if inEvent && start != nil && summary != "" {
+				last := *start
+				if end != nil && end.After(*start) {
+					last = end.AddDate(0, 0, -1)
+				}
+				for d, n := *start, 0; !d.After(last) && n < maxICalEventDays; d, n = d.AddDate(0, 0, 1), n+1 {
+					holidays = append(holidays, ICalHoliday{UID: uid, Name: summary, Date: d})
+				}
+			}
+			inEvent = false
+		case !inEvent:
+			continue
+		case name == "UID":
+			uid = value
+		case name == "SUMMARY":
+			summary = unescapeICalText(value)
+		case name == "DTSTART":
+			start = parseICalDate(value, params)
+		case name == "DTEND":
+			end = parseICalDate(value, params)
+		}
+	}
+
+	if len(holidays) == 0 {
+		return nil, ErrICalEmpty
+	}
+	return holidays, nil
+}
+
+// unfoldICal menggabungkan baris lanjutan (diawali spasi / tab) ke baris sebelumnya
+func unfoldICal(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// splitICalLine memisah "NAME;PARAM=X:VALUE" menjadi nama, parameter dan nilai
+func splitICalLine(line string) (string, string, string) {
+	colon := strings.Index(line, ":")
+	if colon < 0 {
+		return strings.ToUpper(line), "", ""
+	}
+
+	head, value := line[:colon], line[colon+1:]
+	name, params := head, ""
+	if semi := strings.Index(head, ";"); semi >= 0 {
+		name, params = head[:semi], head[semi+1:]
+	}
+	return strings.ToUpper(name), strings.ToUpper(params), strings.TrimSpace(value)
+}
+
+// parseICalDate tanggal dari DATE (20250101) atau DATE-TIME (20250101T000000Z), jam diabaikan
+func parseICalDate(value, params string) *time.Time {
+	if len(value) < 8 {
+		return nil
+	}
+	date, err := time.Parse("20060102", value[:8])
+	if err != nil {
+		return nil
+	}
+
+	// DATE-TIME UTC dipindah ke WIB supaya tidak mundur sehari
+	if !strings.Contains(params, "VALUE=DATE") && strings.HasSuffix(value, "Z") {
+		if t, err := time.Parse("20060102T150405Z", value); err == nil {
+			local := t.In(time.FixedZone("WIB", 7*60*60))
+			date = time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+		}
+	}
+	return &date
+}
+
+func unescapeICalText(value string) string {
+	replacer := strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`)
+	return strings.TrimSpace(replacer.Replace(value))
+}
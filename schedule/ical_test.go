@@ -0,0 +1,119 @@
+package schedule
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseICal(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string // "YYYY-MM-DD nama"
+	}{
+		{
+			name: "all-day DTEND eksklusif satu hari",
+			input: event(
+				"UID:nyepi",
+				"SUMMARY:Hari Raya Nyepi",
+				"DTSTART;VALUE=DATE:20260319",
+				"DTEND;VALUE=DATE:20260320",
+			),
+			want: []string{"2026-03-19 Hari Raya Nyepi"},
+		},
+		{
+			name: "all-day beberapa hari tanpa tanggal DTEND",
+			input: event(
+				"UID:lebaran",
+				"SUMMARY:Idul Fitri",
+				"DTSTART;VALUE=DATE:20260320",
+				"DTEND;VALUE=DATE:20260322",
+			),
+			want: []string{"2026-03-20 Idul Fitri", "2026-03-21 Idul Fitri"},
+		},
+		{
+			name: "tanpa DTEND dianggap satu hari",
+			input: event(
+				"SUMMARY:Tahun Baru",
+				"DTSTART;VALUE=DATE:20260101",
+			),
+			want: []string{"2026-01-01 Tahun Baru"},
+		},
+		{
+			name: "DTEND sama dengan DTSTART dianggap satu hari",
+			input: event(
+				"SUMMARY:Hari Buruh",
+				"DTSTART;VALUE=DATE:20260501",
+				"DTEND;VALUE=DATE:20260501",
+			),
+			want: []string{"2026-05-01 Hari Buruh"},
+		},
+		{
+			name: "baris terlipat digabung sebelum DTEND dibaca",
+			input: event(
+				"SUMMARY:Hari Kemerdekaan",
+				"  Republik Indonesia",
+				"DTSTART;VALUE=DATE:2026",
+				" 0817",
+				"DTEND;VALUE",
+				" =DATE:20260818",
+			),
+			want: []string{"2026-08-17 Hari Kemerdekaan Republik Indonesia"},
+		},
+		{
+			name: "DATE-TIME UTC dipindah ke WIB",
+			input: event(
+				"SUMMARY:Natal",
+				"DTSTART:20261224T170000Z",
+				"DTEND:20261225T170000Z",
+			),
+			want: []string{"2026-12-25 Natal"},
+		},
+		{
+			name: "teks escape dan event tanpa SUMMARY dilewati",
+			input: event(
+				"SUMMARY:Cuti Bersama\\, Idul Fitri",
+				"DTSTART;VALUE=DATE:20260323",
+				"DTEND;VALUE=DATE:20260324",
+			) + event(
+				"DTSTART;VALUE=DATE:20260324",
+			),
+			want: []string{"2026-03-23 Cuti Bersama, Idul Fitri"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			holidays, err := ParseICal(strings.NewReader(calendar(tt.input)))
+			if err != nil {
+				t.Fatalf("ParseICal error = %v", err)
+			}
+
+			got := make([]string, 0, len(holidays))
+			for _, holiday := range holidays {
+				got = append(got, holiday.Date.Format("2006-01-02")+" "+holiday.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseICal = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseICalEmpty(t *testing.T) {
+	_, err := ParseICal(strings.NewReader(calendar("")))
+	if !errors.Is(err, ErrICalEmpty) {
+		t.Errorf("ParseICal error = %v, want ErrICalEmpty", err)
+	}
+}
+
+// calendar membungkus event dalam VCALENDAR dengan akhir baris CRLF seperti file .ics
+func calendar(events string) string {
+	return "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" + events + "END:VCALENDAR\r\n"
+}
+
+func event(lines ...string) string {
+	return "BEGIN:VEVENT\r\n" + strings.Join(lines, "\r\n") + "\r\nEND:VEVENT\r\n"
+}
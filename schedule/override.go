@@ -0,0 +1,168 @@
+package schedule
+
+import (
+	"errors"
+	"time"
+
+	"api_patroliku_docker/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrShiftNotScheduled = errors.New("shift tidak ada di jadwal guard pada tanggal tersebut")
+	ErrShiftNotFound     = errors.New("shift tidak ditemukan")
+	ErrDoubleBooking     = errors.New("shift bertumpuk dengan shift lain guard")
+)
+
+// Change perubahan jadwal satu user di satu tanggal: melepas dan/atau mengambil satu shift
+type Change struct {
+	UserID uint
+	Date   time.Time
+	Remove uint // shift yang dilepas, 0 = tidak ada
+	Add    uint // shift yang diambil, 0 = tidak ada
+}
+
+// Plan daftar shift user di tanggal perubahan setelah Change diterapkan. Shift yang dilepas harus
+// ada di jadwal, dan shift yang diambil tidak boleh bertumpuk dengan shift lain user termasuk
+// shift malam hari sebelumnya dan shift hari berikutnya.
+func Plan(db *gorm.DB, change Change) ([]uint, error) {
+	days, err := Resolve(db, change.UserID, change.Date.AddDate(0, 0, -1), change.Date.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	date := change.Date.Format("2006-01-02")
+	shiftIDs := []uint{}
+	others := []Shift{}
+	removed := false
+	for _, day := range days {
+		for _, shift := range day.Shifts {
+			if shift.ShiftID == 0 {
+				continue
+			}
+			if day.Date == date {
+				if change.Remove != 0 && uint(shift.ShiftID) == change.Remove && !removed {
+					removed = true
+					continue
+				}
+				shiftIDs = append(shiftIDs, uint(shift.ShiftID))
+			}
+			others = append(others, shift)
+		}
+	}
+
+	if change.Remove != 0 && !removed {
+		return nil, ErrShiftNotScheduled
+	}
+
+	if change.Add == 0 {
+		return shiftIDs, nil
+	}
+
+	var added models.ScheduleShift
+	err = db.Where("id = ? AND deleted_at IS NULL", change.Add).First(&added).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrShiftNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, ok := Window(change.Date, added.StartTime, added.EndTime)
+	if !ok {
+		return nil, ErrShiftNotFound
+	}
+	for _, other := range others {
+		otherDate, err := time.ParseInLocation("2006-01-02", other.Date, change.Date.Location())
+		if err != nil {
+			continue
+		}
+		otherStart, otherEnd, ok := Window(otherDate, other.StartTime, other.EndTime)
+		if ok && start.Before(otherEnd) && otherStart.Before(end) {
+			return nil, ErrDoubleBooking
+		}
+	}
+
+	return append(shiftIDs, change.Add), nil
+}
+
+// Override menjadikan shiftIDs jadwal bertanggal user di date, menggantikan pola mingguan.
+// Baris bertanggal yang masih dipakai dipertahankan supaya absensi yang merujuknya tetap valid;
+// tanpa shift sama sekali, tanggal tersebut dicatat sebagai libur.
+func Override(tx *gorm.DB, userID uint, date time.Time, shiftIDs []uint) error {
+	day := date.Format("2006-01-02")
+
+	var existing []models.Schedule
+	if err := tx.Where("users_id = ? AND DATE(date_check_in) = ?", userID, day).
+		Find(&existing).Error; err != nil {
+		return err
+	}
+
+	want := map[uint]bool{}
+	for _, id := range shiftIDs {
+		want[id] = true
+	}
+
+	for _, row := range existing {
+		keep := !row.Holiday && row.ScheduleShiftID != nil && want[*row.ScheduleShiftID]
+		if keep {
+			delete(want, *row.ScheduleShiftID)
+			continue
+		}
+		if err := tx.Delete(&models.Schedule{}, row.ID).Error; err != nil {
+			return err
+		}
+	}
+
+	dayNum := DayNumber(date)
+	dateCheckIn := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+
+	if len(shiftIDs) == 0 {
+		return tx.Create(&models.Schedule{
+			UsersID:     userID,
+			Day:         &dayNum,
+			DateCheckIn: &dateCheckIn,
+			Holiday:     true,
+		}).Error
+	}
+
+	for _, id := range shiftIDs {
+		if !want[id] {
+			continue
+		}
+		delete(want, id)
+
+		shiftID := id
+		if err := tx.Create(&models.Schedule{
+			UsersID:         userID,
+			Day:             &dayNum,
+			DateCheckIn:     &dateCheckIn,
+			ScheduleShiftID: &shiftID,
+		}).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Apply menerapkan beberapa perubahan sekaligus. Semua perubahan divalidasi dulu sebelum ditulis,
+// jalankan di dalam transaksi.
+func Apply(tx *gorm.DB, changes ...Change) error {
+	plans := make([][]uint, len(changes))
+	for i, change := range changes {
+		shiftIDs, err := Plan(tx, change)
+		if err != nil {
+			return err
+		}
+		plans[i] = shiftIDs
+	}
+
+	for i, change := range changes {
+		if err := Override(tx, change.UserID, change.Date, plans[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package schedule
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MaxRangeDays batas panjang rentang tanggal yang diresolusi sekaligus (leave, rekap attendance)
+const MaxRangeDays = 366
+
+// Shift satu shift terjadwal user di tanggal tertentu
+type Shift struct {
+	UserID     uint   `json:"-"`
+	Date       string `json:"date"`
+	ScheduleID int    `json:"schedule_id"`
+	ShiftID    int    `json:"shift_id"`
+	ShiftName  string `json:"shift_name"`
+	StartTime  string `json:"start_time"`
+	EndTime    string `json:"end_time"`
+	Overnight  bool   `json:"overnight"`
+	Dated      bool   `json:"-"` // dari jadwal bertanggal, bukan pola mingguan
+
+	// shift jatuh di hari libur kalender, dihitung premi
+	HolidayPremium bool `json:"holiday_premium"`
+}
+
+// Day jadwal user di satu tanggal setelah override diterapkan
+type Day struct {
+	UserID  uint    `json:"user_id"`
+	Date    string  `json:"date"`
+	Holiday bool    `json:"holiday"` // salah satu jadwal yang berlaku ditandai libur
+	Dated   bool    `json:"dated"`   // jadwal bertanggal menggantikan pola mingguan
+	Shifts  []Shift `json:"shifts"`  // tanpa jadwal libur
+
+	// hari libur kalender (nasional, regional, company); shift tetap berjalan dengan premi
+	PublicHoliday *Holiday `json:"public_holiday"`
+}
+
+type row struct {
+	ScheduleID  int        `gorm:"column:schedule_id"`
+	UserID      uint       `gorm:"column:user_id"`
+	Day         int        `gorm:"column:day"`
+	DateCheckIn *time.Time `gorm:"column:date_check_in"`
+	Holiday     bool       `gorm:"column:holiday"`
+	ShiftID     *int       `gorm:"column:shift_id"`
+	ShiftName   *string    `gorm:"column:shift_name"`
+	StartTime   *string    `gorm:"column:start_time"`
+	EndTime     *string    `gorm:"column:end_time"`
+	Overnight   bool       `gorm:"column:overnight"`
+}
+
+// Resolve jadwal user per tanggal di rentang [start, end] (inklusif).
+// Pola mingguan (schedule.day) berlaku setiap minggu; jadwal bertanggal (schedule.date_check_in),
+// misalnya tukar shift, shift tambahan atau libur, menggantikan seluruh pola mingguan di tanggal itu.
+func Resolve(db *gorm.DB, userID uint, start, end time.Time) ([]Day, error) {
+	days, err := resolve(db, "s.users_id = ?", userID, start, end, userID)
+	if err != nil {
+		return nil, err
+	}
+	return days[userID], nil
+}
+
+// ResolveBranch seperti Resolve untuk semua user di branch, dikelompokkan per user.
+// User tanpa jadwal sama sekali tidak ada di hasil.
+func ResolveBranch(db *gorm.DB, branchID uint, start, end time.Time) (map[uint][]Day, error) {
+	return resolve(db, "s.users_id IN (SELECT user_id FROM user_tad_information WHERE branch_id = ?)", branchID, start, end)
+}
+
+// OnDate jadwal user di satu tanggal
+func OnDate(db *gorm.DB, userID uint, date time.Time) (Day, error) {
+	days, err := Resolve(db, userID, date, date)
+	if err != nil || len(days) == 0 {
+		return Day{UserID: userID, Date: date.Format("2006-01-02"), Shifts: []Shift{}}, err
+	}
+	return days[0], nil
+}
+
+// Shifts daftar shift user di rentang tanggal (inklusif), hari libur tidak dihitung
+func Shifts(db *gorm.DB, userID uint, start, end time.Time) ([]Shift, error) {
+	days, err := Resolve(db, userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	shifts := []Shift{}
+	for _, day := range days {
+		shifts = append(shifts, day.Shifts...)
+	}
+	return shifts, nil
+}
+
+// resolve jadwal user yang cocok dengan where; users selalu ikut di hasil walaupun tanpa jadwal
+func resolve(db *gorm.DB, where string, arg interface{}, start, end time.Time, users ...uint) (map[uint][]Day, error) {
+	var rows []row
+	err := db.Raw(`
+		SELECT
+			s.id AS schedule_id,
+			s.users_id AS user_id,
+			s.day,
+			s.date_check_in,
+			COALESCE(s.holiday, FALSE) AS holiday,
+			ss.id AS shift_id,
+			ss.name AS shift_name,
+			ss.start_time,
+			ss.end_time,
+			COALESCE(ss.overnight, FALSE) AS overnight
+		FROM schedule s
+		LEFT JOIN schedule_shift ss ON ss.id = s.schedule_shift_id
+		WHERE `+where+`
+		  AND (s.date_check_in IS NULL OR DATE(s.date_check_in) BETWEEN ? AND ?)
+		ORDER BY s.id ASC
+	`, arg, start.Format("2006-01-02"), end.Format("2006-01-02")).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	type dateKey struct {
+		userID uint
+		date   string
+	}
+	dated := map[dateKey][]row{}
+	weekly := map[uint]map[int][]row{}
+	for _, userID := range users {
+		weekly[userID] = map[int][]row{}
+	}
+	for _, r := range rows {
+		if _, ok := weekly[r.UserID]; !ok {
+			weekly[r.UserID] = map[int][]row{}
+			users = append(users, r.UserID)
+		}
+		if r.DateCheckIn != nil {
+			k := dateKey{r.UserID, r.DateCheckIn.Format("2006-01-02")}
+			dated[k] = append(dated[k], r)
+		} else {
+			weekly[r.UserID][r.Day] = append(weekly[r.UserID][r.Day], r)
+		}
+	}
+
+	calendar, err := holidays(db, users, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[uint][]Day{}
+	for _, userID := range users {
+		days := []Day{}
+		for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+			date := d.Format("2006-01-02")
+
+			day := Day{UserID: userID, Date: date, Shifts: []Shift{}}
+			if holiday, ok := calendar[userID][date]; ok {
+				day.PublicHoliday = &holiday
+			}
+			dayRows, ok := dated[dateKey{userID, date}]
+			if ok {
+				day.Dated = true
+			} else {
+				dayRows = weekly[userID][DayNumber(d)]
+			}
+
+			for _, r := range dayRows {
+				if r.Holiday {
+					day.Holiday = true
+					continue
+				}
+				shift := r.shift(date)
+				shift.HolidayPremium = day.PublicHoliday != nil
+				day.Shifts = append(day.Shifts, shift)
+			}
+			days = append(days, day)
+		}
+		result[userID] = days
+	}
+
+	return result, nil
+}
+
+func (r row) shift(date string) Shift {
+	shift := Shift{
+		UserID:     r.UserID,
+		Date:       date,
+		ScheduleID: r.ScheduleID,
+		Overnight:  r.Overnight,
+		Dated:      r.DateCheckIn != nil,
+	}
+	if r.ShiftID != nil {
+		shift.ShiftID = *r.ShiftID
+	}
+	if r.ShiftName != nil {
+		shift.ShiftName = *r.ShiftName
+	}
+	if r.StartTime != nil {
+		shift.StartTime = *r.StartTime
+	}
+	if r.EndTime != nil {
+		shift.EndTime = *r.EndTime
+	}
+	return shift
+}
+
+// DayNumber nomor hari sesuai kolom schedule.day (Senin = 1 ... Minggu = 7)
+func DayNumber(t time.Time) int {
+	if t.Weekday() == time.Sunday {
+		return 7
+	}
+	return int(t.Weekday())
+}
+
+// Window jam mulai dan selesai shift di tanggal date; shift yang melewati tengah malam selesai esok hari
+func Window(date time.Time, startTime, endTime string) (time.Time, time.Time, bool) {
+	start, okStart := ParseClock(startTime)
+	end, okEnd := ParseClock(endTime)
+	if !okStart || !okEnd {
+		return time.Time{}, time.Time{}, false
+	}
+
+	from := time.Date(date.Year(), date.Month(), date.Day(), start.Hour(), start.Minute(), start.Second(), 0, date.Location())
+	to := time.Date(date.Year(), date.Month(), date.Day(), end.Hour(), end.Minute(), end.Second(), 0, date.Location())
+	if !to.After(from) {
+		to = to.AddDate(0, 0, 1)
+	}
+	return from, to, true
+}
+
+// ParseClock jam HH:MM atau HH:MM:SS
+func ParseClock(clock string) (time.Time, bool) {
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if t, err := time.Parse(layout, clock); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
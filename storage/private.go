@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"fmt"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultPrivateDir folder file yang tidak boleh diakses publik (tidak di-serve router.Static)
+const defaultPrivateDir = "private_uploads"
+
+// MaxDocumentSize batas ukuran satu file dokumen (10 MB)
+const MaxDocumentSize = 10 << 20
+
+var documentExtensions = map[string]bool{
+	".pdf": true,
+}
+
+// PrivateDir folder file privat, bisa diganti lewat env PRIVATE_UPLOAD_DIR
+func PrivateDir() string {
+	if dir := os.Getenv("PRIVATE_UPLOAD_DIR"); dir != "" {
+		return dir
+	}
+	return defaultPrivateDir
+}
+
+// IsDocument cek ekstensi file dokumen (PDF)
+func IsDocument(fileHeader *multipart.FileHeader) bool {
+	return documentExtensions[strings.ToLower(filepath.Ext(fileHeader.Filename))]
+}
+
+// SavePrivateFile menyimpan file ke <PrivateDir>/<dir> dan mengembalikan path relatif terhadap PrivateDir.
+// File hanya bisa diunduh lewat endpoint yang memeriksa hak akses.
+func SavePrivateFile(c *gin.Context, fileHeader *multipart.FileHeader, dir string) (string, error) {
+	filePath, err := saveFile(c, fileHeader, filepath.Join(PrivateDir(), dir))
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(PrivateDir(), filePath)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.ToSlash(rel), nil
+}
+
+// PrivatePath mengubah path relatif hasil SavePrivateFile menjadi path di disk.
+// Path yang keluar dari PrivateDir ditolak.
+func PrivatePath(rel string) (string, error) {
+	base, err := filepath.Abs(PrivateDir())
+	if err != nil {
+		return "", err
+	}
+
+	full, err := filepath.Abs(filepath.Join(base, filepath.FromSlash(rel)))
+	if err != nil {
+		return "", err
+	}
+
+	if !strings.HasPrefix(full, base+string(os.PathSeparator)) {
+		return "", fmt.Errorf("path file tidak valid: %s", rel)
+	}
+
+	return full, nil
+}
+
+// RemovePrivateFile menghapus file privat, dipakai untuk rollback saat simpan data gagal
+func RemovePrivateFile(rel string) error {
+	full, err := PrivatePath(rel)
+	if err != nil {
+		return err
+	}
+	return os.Remove(full)
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"fmt"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PublicDir folder upload yang di-serve lewat router.Static("/uploads")
+const PublicDir = "uploads"
+
+var imageExtensions = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".webp": true, ".heic": true,
+}
+
+var videoExtensions = map[string]bool{
+	".mp4": true, ".mov": true, ".3gp": true, ".webm": true,
+}
+
+// IsImage cek ekstensi file gambar
+func IsImage(fileHeader *multipart.FileHeader) bool {
+	return imageExtensions[strings.ToLower(filepath.Ext(fileHeader.Filename))]
+}
+
+// IsVideo cek ekstensi file video
+func IsVideo(fileHeader *multipart.FileHeader) bool {
+	return videoExtensions[strings.ToLower(filepath.Ext(fileHeader.Filename))]
+}
+
+// SaveUploadedFile menyimpan file ke uploads/<dir> dan mengembalikan URL lengkapnya
+func SaveUploadedFile(c *gin.Context, fileHeader *multipart.FileHeader, dir string) (string, error) {
+	filePath, err := saveFile(c, fileHeader, filepath.Join(PublicDir, dir))
+	if err != nil {
+		return "", err
+	}
+
+	return PublicURL(c, filePath), nil
+}
+
+// PublicURL membuat URL lengkap (scheme + host) untuk path di folder uploads
+func PublicURL(c *gin.Context, filePath string) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+
+	return scheme + "://" + c.Request.Host + "/" + filepath.ToSlash(filePath)
+}
+
+func saveFile(c *gin.Context, fileHeader *multipart.FileHeader, uploadDir string) (string, error) {
+	if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
+		return "", err
+	}
+
+	filename := strconv.FormatInt(time.Now().UnixNano(), 10) +
+		strings.ToLower(filepath.Ext(fileHeader.Filename))
+
+	filePath := filepath.Join(uploadDir, filename)
+
+	if err := c.SaveUploadedFile(fileHeader, filePath); err != nil {
+		return "", fmt.Errorf("gagal menyimpan file %s: %v", fileHeader.Filename, err)
+	}
+
+	return filePath, nil
+}
+
+// RemovePublicFile menghapus file di folder uploads dari URL hasil SaveUploadedFile
+func RemovePublicFile(fileURL string) error {
+	marker := "/" + PublicDir + "/"
+	i := strings.Index(fileURL, marker)
+	if i < 0 {
+		return fmt.Errorf("bukan file upload: %s", fileURL)
+	}
+
+	rel := filepath.Clean(filepath.FromSlash(fileURL[i+len(marker):]))
+	if rel == "." || strings.HasPrefix(rel, "..") || filepath.IsAbs(rel) {
+		return fmt.Errorf("path file tidak valid: %s", fileURL)
+	}
+
+	err := os.Remove(filepath.Join(PublicDir, rel))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,98 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrChecklistIncomplete = errors.New("checklist wajib belum lengkap")
+
+// ChecklistEntry satu item checklist beserta jawabannya di penugasan
+type ChecklistEntry struct {
+	ItemID      uint       `json:"item_id"`
+	Label       string     `json:"label"`
+	Description string     `json:"description"`
+	Required    bool       `json:"required"`
+	SortOrder   int        `json:"sort_order"`
+	Result      *string    `json:"result"`
+	Comment     *string    `json:"comment"`
+	Photo       *string    `json:"photo"`
+	AnsweredAt  *time.Time `json:"answered_at"`
+}
+
+// ChecklistProgress ringkasan pengisian checklist penugasan
+type ChecklistProgress struct {
+	Total            int  `json:"total"`
+	Answered         int  `json:"answered"`
+	Required         int  `json:"required"`
+	RequiredAnswered int  `json:"required_answered"`
+	Percent          int  `json:"percent"`
+	Complete         bool `json:"complete"`
+}
+
+// Checklist item checklist dari tipe task penugasan beserta jawaban dan progresnya.
+// Item yang sudah dihapus tetap tampil jika sudah dijawab.
+func Checklist(db *gorm.DB, assignID uint) ([]ChecklistEntry, ChecklistProgress, error) {
+	entries := []ChecklistEntry{}
+	var progress ChecklistProgress
+
+	if err := db.Raw(`
+		SELECT
+			ci.id AS item_id,
+			ci.label,
+			ci.description,
+			ci.required AND ci.deleted_at IS NULL AS required,
+			ci.sort_order,
+			r.result,
+			r.comment,
+			r.photo,
+			r.updated_at AS answered_at
+		FROM task_assign ta
+		JOIN task t ON t.id = ta.task_id
+		JOIN task_checklist_item ci ON ci.task_type_id = t.task_type_id
+		LEFT JOIN task_checklist_result r ON r.checklist_item_id = ci.id AND r.task_assign_id = ta.id
+		WHERE ta.id = ?
+		  AND (ci.deleted_at IS NULL OR r.id IS NOT NULL)
+		ORDER BY ci.sort_order ASC, ci.id ASC
+	`, assignID).Scan(&entries).Error; err != nil {
+		return nil, progress, err
+	}
+
+	for _, entry := range entries {
+		progress.Total++
+		if entry.Result != nil {
+			progress.Answered++
+		}
+		if entry.Required {
+			progress.Required++
+			if entry.Result != nil {
+				progress.RequiredAnswered++
+			}
+		}
+	}
+
+	progress.Percent = 100
+	if progress.Total > 0 {
+		progress.Percent = progress.Answered * 100 / progress.Total
+	}
+	progress.Complete = progress.RequiredAnswered == progress.Required
+
+	return entries, progress, nil
+}
+
+// CheckChecklist memastikan semua item wajib sudah dijawab sebelum task disubmit
+func CheckChecklist(db *gorm.DB, assignID uint) error {
+	_, progress, err := Checklist(db, assignID)
+	if err != nil {
+		return err
+	}
+
+	if !progress.Complete {
+		return fmt.Errorf("%w: %d dari %d item wajib belum dijawab",
+			ErrChecklistIncomplete, progress.Required-progress.RequiredAnswered, progress.Required)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package tasks
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"api_patroliku_docker/models"
+	"api_patroliku_docker/schedule"
+)
+
+// Recurrence menentukan menit mana saja template task muncul
+type Recurrence interface {
+	Match(t time.Time) bool
+}
+
+// weeklyRecurrence muncul di hari tertentu (1=Senin ... 7=Minggu) pada jam yang sama
+type weeklyRecurrence struct {
+	days   map[int]bool
+	hour   int
+	minute int
+}
+
+func (r weeklyRecurrence) Match(t time.Time) bool {
+	return r.days[schedule.DayNumber(t)] && t.Hour() == r.hour && t.Minute() == r.minute
+}
+
+// cronRecurrence ekspresi cron 5 field: menit jam tanggal bulan hari (0/7 = Minggu)
+type cronRecurrence struct {
+	minute, hour, dom, month, dow map[int]bool
+	domAny, dowAny                bool
+}
+
+func (r cronRecurrence) Match(t time.Time) bool {
+	if !r.minute[t.Minute()] || !r.hour[t.Hour()] || !r.month[int(t.Month())] {
+		return false
+	}
+
+	domMatch := r.dom[t.Day()]
+	dowMatch := r.dow[int(t.Weekday())]
+
+	// seperti cron standar: jika tanggal & hari sama-sama dibatasi, cukup salah satu cocok
+	switch {
+	case r.domAny && r.dowAny:
+		return true
+	case r.domAny:
+		return dowMatch
+	case r.dowAny:
+		return domMatch
+	}
+	return domMatch || dowMatch
+}
+
+// NewRecurrence membuat Recurrence dari konfigurasi template
+func NewRecurrence(tpl models.TaskTemplate) (Recurrence, error) {
+	switch tpl.Recurrence {
+	case models.TaskRecurrenceWeekly:
+		return parseWeekly(tpl.Weekdays, tpl.TimeOfDay)
+	case models.TaskRecurrenceCron:
+		return ParseCron(tpl.CronExpr)
+	}
+	return nil, fmt.Errorf("recurrence harus weekly atau cron")
+}
+
+func parseWeekly(weekdays, timeOfDay string) (Recurrence, error) {
+	r := weeklyRecurrence{days: map[int]bool{}}
+
+	for _, part := range strings.Split(weekdays, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		day, err := strconv.Atoi(part)
+		if err != nil || day < 1 || day > 7 {
+			return nil, fmt.Errorf("weekdays harus berisi angka 1 (Senin) sampai 7 (Minggu)")
+		}
+		r.days[day] = true
+	}
+	if len(r.days) == 0 {
+		return nil, fmt.Errorf("weekdays wajib diisi untuk recurrence weekly")
+	}
+
+	t, err := time.Parse("15:04", timeOfDay)
+	if err != nil {
+		return nil, fmt.Errorf("format time_of_day harus HH:MM")
+	}
+	r.hour, r.minute = t.Hour(), t.Minute()
+
+	return r, nil
+}
+
+// ParseCron parse ekspresi cron 5 field. Mendukung *, angka, rentang (a-b), daftar (a,b) dan langkah (*/n, a-b/n).
+func ParseCron(expr string) (Recurrence, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron_expr harus 5 field: menit jam tanggal bulan hari")
+	}
+
+	var r cronRecurrence
+	var err error
+
+	if r.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("cron menit: %v", err)
+	}
+	if r.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("cron jam: %v", err)
+	}
+	if r.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("cron tanggal: %v", err)
+	}
+	if r.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("cron bulan: %v", err)
+	}
+	if r.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("cron hari: %v", err)
+	}
+
+	// 7 juga berarti Minggu
+	if r.dow[7] {
+		r.dow[0] = true
+	}
+
+	r.domAny = fields[2] == "*"
+	r.dowAny = fields[4] == "*"
+
+	return r, nil
+}
+
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+	values := map[int]bool{}
+
+	for _, part := range strings.Split(field, ",") {
+		step, stepped := 1, false
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("langkah tidak valid: %s", part)
+			}
+			step, stepped = n, true
+			part = part[:i]
+		}
+
+		lo, hi := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			a, errA := strconv.Atoi(bounds[0])
+			b, errB := strconv.Atoi(bounds[1])
+			if errA != nil || errB != nil || a > b {
+				return nil, fmt.Errorf("rentang tidak valid: %s", part)
+			}
+			lo, hi = a, b
+		default:
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, fmt.Errorf("nilai tidak valid: %s", part)
+			}
+			lo, hi = n, n
+			// "5/15" berarti 5-max/15 seperti cron standar
+			if stepped {
+				hi = max
+			}
+		}
+
+		if lo < min || hi > max {
+			return nil, fmt.Errorf("nilai %s di luar rentang %d-%d", part, min, max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			values[v] = true
+		}
+	}
+
+	return values, nil
+}
+
+// Occurrences semua waktu kemunculan (per menit) di rentang [from, to)
+func Occurrences(r Recurrence, from, to time.Time) []time.Time {
+	var result []time.Time
+	for t := from.Truncate(time.Minute); t.Before(to); t = t.Add(time.Minute) {
+		if t.Before(from) {
+			continue
+		}
+		if r.Match(t) {
+			result = append(result, t)
+		}
+	}
+	return result
+}
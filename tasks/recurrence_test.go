@@ -0,0 +1,98 @@
+package tasks
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestParseCronField(t *testing.T) {
+	tests := []struct {
+		field    string
+		min, max int
+		want     []int
+		wantErr  bool
+	}{
+		{field: "5", min: 0, max: 59, want: []int{5}},
+		{field: "1,3,5", min: 0, max: 59, want: []int{1, 3, 5}},
+		{field: "8-11", min: 0, max: 23, want: []int{8, 9, 10, 11}},
+		{field: "*/15", min: 0, max: 59, want: []int{0, 15, 30, 45}},
+		{field: "5/15", min: 0, max: 59, want: []int{5, 20, 35, 50}},
+		{field: "10-20/5", min: 0, max: 59, want: []int{10, 15, 20}},
+		{field: "1-5,0", min: 0, max: 7, want: []int{0, 1, 2, 3, 4, 5}},
+		{field: "*", min: 1, max: 12, want: []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}},
+		{field: "60", min: 0, max: 59, wantErr: true},
+		{field: "0", min: 1, max: 31, wantErr: true},
+		{field: "5-1", min: 0, max: 59, wantErr: true},
+		{field: "*/0", min: 0, max: 59, wantErr: true},
+		{field: "a", min: 0, max: 59, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseCronField(tt.field, tt.min, tt.max)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseCronField(%q) error = nil, want error", tt.field)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseCronField(%q) error = %v", tt.field, err)
+			continue
+		}
+
+		values := make([]int, 0, len(got))
+		for v := range got {
+			values = append(values, v)
+		}
+		sort.Ints(values)
+		if !reflect.DeepEqual(values, tt.want) {
+			t.Errorf("parseCronField(%q) = %v, want %v", tt.field, values, tt.want)
+		}
+	}
+}
+
+func TestParseCronMatch(t *testing.T) {
+	// 2026-03-01 hari Minggu
+	sunday := time.Date(2026, 3, 1, 7, 30, 0, 0, time.UTC)
+	monday := sunday.AddDate(0, 0, 1)
+
+	tests := []struct {
+		expr string
+		at   time.Time
+		want bool
+	}{
+		{expr: "30 7 * * 0", at: sunday, want: true},
+		{expr: "30 7 * * 7", at: sunday, want: true},
+		{expr: "30 7 * * 7", at: monday, want: false},
+		{expr: "30 7 * * 1-5", at: monday, want: true},
+		{expr: "30 7 * * 1-5", at: sunday, want: false},
+		{expr: "*/15 7 * * *", at: sunday, want: true},
+		{expr: "5/15 7 * * *", at: sunday, want: false},
+		{expr: "30 8 * * *", at: sunday, want: false},
+		// tanggal dan hari sama-sama dibatasi: cukup salah satu cocok
+		{expr: "30 7 15 * 1", at: monday, want: true},
+		{expr: "30 7 1 * 1", at: sunday, want: true},
+		{expr: "30 7 15 * 1", at: sunday, want: false},
+	}
+
+	for _, tt := range tests {
+		r, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Errorf("ParseCron(%q) error = %v", tt.expr, err)
+			continue
+		}
+		if got := r.Match(tt.at); got != tt.want {
+			t.Errorf("ParseCron(%q).Match(%s) = %v, want %v", tt.expr, tt.at.Format("2006-01-02 15:04 Mon"), got, tt.want)
+		}
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "* * * * * *", "* 24 * * *", "* * * 13 *", "* * * * 8"} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) error = nil, want error", expr)
+		}
+	}
+}
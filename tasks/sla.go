@@ -0,0 +1,123 @@
+package tasks
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"api_patroliku_docker/models"
+
+	"gorm.io/gorm"
+)
+
+// HistoryTimesJoin join waktu pertama penugasan dimulai, disubmit dan overdue dari riwayat status (alias sla).
+// Query utama harus memakai alias ta untuk task_assign.
+const HistoryTimesJoin = `LEFT JOIN (
+	SELECT
+		task_assign_id,
+		MIN(created_at) FILTER (WHERE to_status = 'in_progress') AS started_at,
+		MIN(created_at) FILTER (WHERE to_status = 'submitted') AS submitted_at,
+		MIN(created_at) FILTER (WHERE to_status = 'overdue') AS overdue_at
+	FROM task_assign_history
+	GROUP BY task_assign_id
+) sla ON sla.task_assign_id = ta.id`
+
+// SLA waktu pengerjaan satu penugasan terhadap jendela start_time - end_time
+type SLA struct {
+	StartTime   *time.Time `json:"start_time"`
+	EndTime     *time.Time `json:"end_time"`
+	StartedAt   *time.Time `json:"started_at"`
+	SubmittedAt *time.Time `json:"submitted_at"`
+	OverdueAt   *time.Time `json:"overdue_at"`
+
+	// menit dari start_time sampai mulai dikerjakan (negatif jika mulai lebih awal)
+	TimeToStartMinutes *int `json:"time_to_start_minutes" gorm:"-"`
+	// menit dari mulai dikerjakan sampai disubmit
+	TimeToCompleteMinutes *int `json:"time_to_complete_minutes" gorm:"-"`
+	// kosong selama belum disubmit dan belum lewat end_time
+	OnTime *bool `json:"on_time" gorm:"-"`
+}
+
+// AssignSLA menghitung SLA satu penugasan dari riwayat statusnya
+func AssignSLA(db *gorm.DB, assignID uint) (SLA, error) {
+	var sla SLA
+	err := db.Raw(`
+		SELECT
+			ta.start_time,
+			ta.end_time,
+			sla.started_at,
+			sla.submitted_at,
+			sla.overdue_at
+		FROM task_assign ta
+		`+HistoryTimesJoin+`
+		WHERE ta.id = ?
+	`, assignID).Scan(&sla).Error
+	if err != nil {
+		return sla, err
+	}
+
+	sla.compute(time.Now())
+	return sla, nil
+}
+
+func (s *SLA) compute(now time.Time) {
+	if s.StartTime != nil && s.StartedAt != nil {
+		minutes := int(s.StartedAt.Sub(*s.StartTime).Minutes())
+		s.TimeToStartMinutes = &minutes
+	}
+
+	if s.StartedAt != nil && s.SubmittedAt != nil {
+		minutes := int(s.SubmittedAt.Sub(*s.StartedAt).Minutes())
+		s.TimeToCompleteMinutes = &minutes
+	}
+
+	if s.EndTime == nil {
+		return
+	}
+	switch {
+	case s.SubmittedAt != nil:
+		onTime := !s.SubmittedAt.After(*s.EndTime)
+		s.OnTime = &onTime
+	case now.After(*s.EndTime):
+		onTime := false
+		s.OnTime = &onTime
+	}
+}
+
+// MarkOverdue mengubah penugasan yang melewati end_time dan belum dikerjakan selesai menjadi overdue.
+// Penugasan yang pernah overdue lalu dimulai guard tidak ditandai ulang.
+// Perubahan dicatat sebagai aksi sistem. Mengembalikan penugasan yang berhasil diubah.
+func MarkOverdue(db *gorm.DB, now time.Time) ([]models.TaskAssign, error) {
+	var candidates []models.TaskAssign
+	if err := db.Where("status IN ? AND end_time IS NOT NULL AND end_time < ?",
+		[]string{models.TaskStatusAssigned, models.TaskStatusInProgress}, now).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM task_assign_history h
+			WHERE h.task_assign_id = task_assign.id AND h.to_status = ?
+		)`, models.TaskStatusOverdue).
+		Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+
+	var marked []models.TaskAssign
+	for _, assign := range candidates {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			_, err := Transition(tx, assign.ID, models.TaskStatusOverdue, 0, "melewati batas waktu")
+			return err
+		})
+
+		// status sudah berubah sejak dibaca, misal guard baru saja submit
+		if errors.Is(err, ErrStatusChanged) || errors.Is(err, ErrTransitionDenied) {
+			continue
+		}
+		if err != nil {
+			log.Printf("⚠️ Gagal menandai task assign %d overdue: %v", assign.ID, err)
+			continue
+		}
+
+		assign.Status = models.TaskStatusOverdue
+		marked = append(marked, assign)
+	}
+
+	return marked, nil
+}
@@ -0,0 +1,106 @@
+package tasks
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSLACompute(t *testing.T) {
+	base := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	at := func(minutes int) *time.Time {
+		v := base.Add(time.Duration(minutes) * time.Minute)
+		return &v
+	}
+
+	tests := []struct {
+		name           string
+		sla            SLA
+		now            time.Time
+		wantOnTime     *bool
+		wantToStart    *int
+		wantToComplete *int
+	}{
+		{
+			name:           "on_time submit sebelum end_time",
+			sla:            SLA{StartTime: at(0), EndTime: at(60), StartedAt: at(10), SubmittedAt: at(50)},
+			now:            *at(120),
+			wantOnTime:     boolPtr(true),
+			wantToStart:    intPtr(10),
+			wantToComplete: intPtr(40),
+		},
+		{
+			name:           "on_time submit tepat di end_time",
+			sla:            SLA{StartTime: at(0), EndTime: at(60), StartedAt: at(0), SubmittedAt: at(60)},
+			now:            *at(120),
+			wantOnTime:     boolPtr(true),
+			wantToStart:    intPtr(0),
+			wantToComplete: intPtr(60),
+		},
+		{
+			name:           "late submit setelah end_time",
+			sla:            SLA{StartTime: at(0), EndTime: at(60), StartedAt: at(30), SubmittedAt: at(61)},
+			now:            *at(120),
+			wantOnTime:     boolPtr(false),
+			wantToStart:    intPtr(30),
+			wantToComplete: intPtr(31),
+		},
+		{
+			name:        "missed belum submit dan end_time lewat",
+			sla:         SLA{StartTime: at(0), EndTime: at(60), StartedAt: at(-5)},
+			now:         *at(61),
+			wantOnTime:  boolPtr(false),
+			wantToStart: intPtr(-5),
+		},
+		{
+			name: "belum submit tepat di end_time masih berjalan",
+			sla:  SLA{StartTime: at(0), EndTime: at(60)},
+			now:  *at(60),
+		},
+		{
+			name:           "tanpa end_time tidak dinilai, durasi tetap dihitung",
+			sla:            SLA{StartTime: at(0), StartedAt: at(5), SubmittedAt: at(500)},
+			now:            *at(1000),
+			wantToStart:    intPtr(5),
+			wantToComplete: intPtr(495),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sla := tt.sla
+			sla.compute(tt.now)
+
+			if !equalBool(sla.OnTime, tt.wantOnTime) {
+				t.Errorf("OnTime = %v, want %v", derefBool(sla.OnTime), derefBool(tt.wantOnTime))
+			}
+			if !equalInt(sla.TimeToStartMinutes, tt.wantToStart) {
+				t.Errorf("TimeToStartMinutes = %v, want %v", derefInt(sla.TimeToStartMinutes), derefInt(tt.wantToStart))
+			}
+			if !equalInt(sla.TimeToCompleteMinutes, tt.wantToComplete) {
+				t.Errorf("TimeToCompleteMinutes = %v, want %v", derefInt(sla.TimeToCompleteMinutes), derefInt(tt.wantToComplete))
+			}
+		})
+	}
+}
+
+func boolPtr(v bool) *bool { return &v }
+
+func intPtr(v int) *int { return &v }
+
+func equalBool(a, b *bool) bool { return (a == nil && b == nil) || (a != nil && b != nil && *a == *b) }
+
+func equalInt(a, b *int) bool { return (a == nil && b == nil) || (a != nil && b != nil && *a == *b) }
+
+func derefBool(v *bool) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+func derefInt(v *int) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
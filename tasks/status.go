@@ -0,0 +1,121 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"api_patroliku_docker/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAssignNotFound   = errors.New("task assign tidak ditemukan")
+	ErrStatusChanged    = errors.New("status task sudah diubah oleh user lain")
+	ErrInvalidStatus    = errors.New("status task tidak dikenal")
+	ErrTransitionDenied = errors.New("perubahan status task tidak diizinkan")
+)
+
+// transitions status tujuan yang boleh dicapai dari tiap status
+var transitions = map[string][]string{
+	models.TaskStatusAssigned:   {models.TaskStatusInProgress, models.TaskStatusCancelled, models.TaskStatusOverdue},
+	models.TaskStatusInProgress: {models.TaskStatusSubmitted, models.TaskStatusCancelled, models.TaskStatusOverdue},
+	models.TaskStatusOverdue:    {models.TaskStatusInProgress, models.TaskStatusSubmitted, models.TaskStatusCancelled},
+	models.TaskStatusSubmitted:  {models.TaskStatusVerified, models.TaskStatusRejected},
+	models.TaskStatusRejected:   {models.TaskStatusInProgress, models.TaskStatusSubmitted, models.TaskStatusCancelled},
+	models.TaskStatusVerified:   {},
+	models.TaskStatusCancelled:  {},
+}
+
+// IsValidStatus cek status dikenal state machine
+func IsValidStatus(status string) bool {
+	_, ok := transitions[status]
+	return ok
+}
+
+// CanTransition cek apakah perubahan status from → to diizinkan
+func CanTransition(from, to string) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// NextStatuses status yang bisa dituju dari status sekarang
+func NextStatuses(from string) []string {
+	return transitions[from]
+}
+
+// IsOpen status yang masih bisa dikerjakan guard
+func IsOpen(status string) bool {
+	switch status {
+	case models.TaskStatusAssigned, models.TaskStatusInProgress,
+		models.TaskStatusOverdue, models.TaskStatusRejected:
+		return true
+	}
+	return false
+}
+
+// Transition mengubah status task_assign sesuai tabel transisi dan mencatat riwayatnya.
+// actorID 0 berarti perubahan oleh sistem. Sebaiknya dipanggil di dalam transaksi.
+func Transition(tx *gorm.DB, assignID uint, to string, actorID int, note string) (string, error) {
+	if !IsValidStatus(to) {
+		return "", ErrInvalidStatus
+	}
+
+	var from string
+	err := tx.Model(&models.TaskAssign{}).
+		Select("status").
+		Where("id = ?", assignID).
+		Take(&from).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", ErrAssignNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if !CanTransition(from, to) {
+		return from, fmt.Errorf("%w: %s → %s", ErrTransitionDenied, from, to)
+	}
+
+	if to == models.TaskStatusSubmitted {
+		if err := CheckChecklist(tx, assignID); err != nil {
+			return from, err
+		}
+	}
+
+	result := tx.Model(&models.TaskAssign{}).
+		Where("id = ? AND status = ?", assignID, from).
+		Updates(map[string]interface{}{
+			"status":     to,
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return from, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return from, ErrStatusChanged
+	}
+
+	return from, RecordHistory(tx, assignID, from, to, actorID, note)
+}
+
+// RecordHistory mencatat satu baris riwayat status, juga dipakai saat penugasan dibuat (from kosong)
+func RecordHistory(tx *gorm.DB, assignID uint, from, to string, actorID int, note string) error {
+	history := models.TaskAssignHistory{
+		TaskAssignID: assignID,
+		FromStatus:   from,
+		ToStatus:     to,
+		Note:         note,
+	}
+	if actorID > 0 {
+		actor := uint(actorID)
+		history.ActorID = &actor
+	}
+
+	return tx.Create(&history).Error
+}
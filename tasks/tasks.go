@@ -0,0 +1,83 @@
+package tasks
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// timeLayouts format waktu yang diterima untuk start_time / end_time
+var timeLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	time.RFC3339,
+}
+
+// ParseTime parse waktu penugasan dalam zona waktu lokal server
+func ParseTime(value string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("format waktu tidak valid: %s (gunakan YYYY-MM-DD HH:MM)", value)
+}
+
+// ParseWindow parse start_time & end_time, end harus setelah start
+func ParseWindow(start, end string) (time.Time, time.Time, error) {
+	startTime, err := ParseTime(start)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	endTime, err := ParseTime(end)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	if !endTime.After(startTime) {
+		return time.Time{}, time.Time{}, fmt.Errorf("end_time harus setelah start_time")
+	}
+
+	return startTime, endTime, nil
+}
+
+// AssignBranchID branch dari task_assign: branch task, atau branch guard untuk task lama tanpa branch
+func AssignBranchID(db *gorm.DB, assignID int) (int, error) {
+	var branchID int
+	err := db.Raw(`
+		SELECT COALESCE(NULLIF(t.branch_id, 0), uti.branch_id, 0)
+		FROM task_assign ta
+		LEFT JOIN task t ON t.id = ta.task_id
+		LEFT JOIN user_tad_information uti ON uti.user_id = ta.user_tad_id
+		WHERE ta.id = ?
+		LIMIT 1
+	`, assignID).Scan(&branchID).Error
+	return branchID, err
+}
+
+// GuardBranches branch tiap guard dari user_tad_information
+func GuardBranches(db *gorm.DB, userIDs []uint) (map[uint]int, error) {
+	type row struct {
+		UserID   uint
+		BranchID int
+	}
+
+	var rows []row
+	if err := db.Raw(`
+		SELECT user_id, branch_id
+		FROM user_tad_information
+		WHERE user_id IN ?
+	`, userIDs).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	branches := make(map[uint]int, len(rows))
+	for _, r := range rows {
+		branches[r.UserID] = r.BranchID
+	}
+	return branches, nil
+}
@@ -0,0 +1,122 @@
+package tasks
+
+import (
+	"time"
+
+	"api_patroliku_docker/models"
+	"api_patroliku_docker/schedule"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GuardsOnShift guard di branch yang sedang menjalani shift pada waktu at, sesuai resolusi
+// jadwal (jadwal bertanggal menggantikan pola mingguan). Shift malam dari hari sebelumnya ikut
+// dihitung. shiftID membatasi ke satu schedule_shift.
+func GuardsOnShift(db *gorm.DB, branchID uint, shiftID *uint, at time.Time) ([]uint, error) {
+	today := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, at.Location())
+	yesterday := today.AddDate(0, 0, -1)
+
+	branchDays, err := schedule.ResolveBranch(db, branchID, yesterday, today)
+	if err != nil {
+		return nil, err
+	}
+
+	var guards []uint
+	for userID, days := range branchDays {
+		if onShift(days, shiftID, at) {
+			guards = append(guards, userID)
+		}
+	}
+
+	return guards, nil
+}
+
+func onShift(days []schedule.Day, shiftID *uint, at time.Time) bool {
+	for _, day := range days {
+		date, err := time.ParseInLocation("2006-01-02", day.Date, at.Location())
+		if err != nil {
+			continue
+		}
+
+		for _, shift := range day.Shifts {
+			if shift.ShiftID == 0 || (shiftID != nil && uint(shift.ShiftID) != *shiftID) {
+				continue
+			}
+
+			start, end, ok := schedule.Window(date, shift.StartTime, shift.EndTime)
+			if ok && !at.Before(start) && at.Before(end) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GenerateFromTemplate membuat task_assign untuk setiap kemunculan template di [from, to)
+// bagi guard yang sedang shift. Aman dipanggil berulang: kombinasi template, guard dan
+// waktu kemunculan unik, sehingga yang sudah ada dilewati. Mengembalikan penugasan yang baru dibuat.
+func GenerateFromTemplate(db *gorm.DB, tpl models.TaskTemplate, from, to time.Time) ([]models.TaskAssign, error) {
+	recurrence, err := NewRecurrence(tpl)
+	if err != nil {
+		return nil, err
+	}
+
+	var created []models.TaskAssign
+	for _, occurrence := range Occurrences(recurrence, from, to) {
+		guards, err := GuardsOnShift(db, tpl.BranchID, tpl.ShiftID, occurrence)
+		if err != nil {
+			return created, err
+		}
+
+		for _, userID := range guards {
+			assign, ok, err := createTemplateAssign(db, tpl, userID, occurrence)
+			if err != nil {
+				return created, err
+			}
+			if ok {
+				created = append(created, assign)
+			}
+		}
+	}
+
+	return created, nil
+}
+
+func createTemplateAssign(db *gorm.DB, tpl models.TaskTemplate, userID uint, occurrence time.Time) (models.TaskAssign, bool, error) {
+	templateID := tpl.ID
+	occurrenceAt := occurrence
+	startTime := occurrence
+	endTime := occurrence.Add(time.Duration(tpl.DurationMinutes) * time.Minute)
+
+	assign := models.TaskAssign{
+		TaskID:       tpl.TaskID,
+		UserTadID:    userID,
+		Status:       models.TaskStatusAssigned,
+		StartTime:    &startTime,
+		EndTime:      &endTime,
+		AssignedBy:   tpl.CreatedBy,
+		TemplateID:   &templateID,
+		OccurrenceAt: &occurrenceAt,
+	}
+	if tpl.Note != "" {
+		note := tpl.Note
+		assign.Note = &note
+	}
+
+	inserted := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&assign)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		inserted = true
+		return RecordHistory(tx, assign.ID, "", assign.Status, 0, "dibuat otomatis dari template")
+	})
+
+	return assign, inserted, err
+}